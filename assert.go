@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+// Exit codes for failed assertions. When several rules fail, the code of the
+// first failing rule in the order returned by rules is used.
+const (
+	exitFetchErrors   = 3
+	exitServerErrors  = 4
+	exitClientErrors  = 5
+	exitTooManyRedirs = 6
+)
+
+// failRule is a CI assertion evaluated over the crawl results. It fails when
+// more than limit resources match.
+type failRule struct {
+	name  string
+	code  int
+	limit int
+	match func(res crawler.Resource) bool
+}
+
+// assertOptions holds the flags that turn crawl results into a pass/fail verdict
+type assertOptions struct {
+	failOn           string
+	maxRedirectCount int
+}
+
+func defaultAssertOptions() assertOptions {
+	return assertOptions{maxRedirectCount: -1}
+}
+
+// register binds the options to flags on fs
+func (o *assertOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.failOn, "fail-on", o.failOn, "comma-separated statuses that fail the crawl: 4xx, 5xx, error")
+	fs.IntVar(&o.maxRedirectCount, "max-redirect-count", o.maxRedirectCount, "fail if more than this many crawled URLs answer with a 3xx redirect; each redirecting URL counts once, however long its chain (-1 to disable)")
+}
+
+// rules converts the options into failRules, in exit code precedence order
+func (o assertOptions) rules() ([]failRule, error) {
+	var rules []failRule
+	want := make(map[string]bool)
+	for _, name := range strings.Split(o.failOn, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		switch name {
+		case "4xx", "5xx", "error":
+			want[name] = true
+		default:
+			return nil, fmt.Errorf("unknown --fail-on value %q (want 4xx, 5xx or error)", name)
+		}
+	}
+
+	if want["error"] {
+		rules = append(rules, failRule{name: "error", code: exitFetchErrors, match: isFetchError})
+	}
+	if want["5xx"] {
+		rules = append(rules, failRule{name: "5xx", code: exitServerErrors, match: statusClass(5)})
+	}
+	if want["4xx"] {
+		rules = append(rules, failRule{name: "4xx", code: exitClientErrors, match: statusClass(4)})
+	}
+	if o.maxRedirectCount >= 0 {
+		rules = append(rules, failRule{name: "redirects", code: exitTooManyRedirs, limit: o.maxRedirectCount, match: statusClass(3)})
+	}
+	return rules, nil
+}
+
+// isFetchError matches resources the collector couldn't fetch or read
+func isFetchError(res crawler.Resource) bool {
+	return res.Status == "Error" || res.Status == "Read Err"
+}
+
+// fetchErrorResource stands in for a URL that failed without a resource, so
+// rules can count it
+func fetchErrorResource(e crawler.Event) crawler.Resource {
+	res := crawler.Resource{URL: e.URL, Status: "Error"}
+	if e.Err != nil {
+		res.Error = e.Err.Error()
+	}
+	return res
+}
+
+// statusClass matches numeric statuses in the given hundred, e.g. 4 for 4xx
+func statusClass(class int) func(res crawler.Resource) bool {
+	return func(res crawler.Resource) bool {
+		code, err := strconv.Atoi(res.Status)
+		return err == nil && code/100 == class
+	}
+}
+
+// ruleFailure is a rule together with the resources that tripped it
+type ruleFailure struct {
+	rule      failRule
+	resources []crawler.Resource
+}
+
+// evaluator tracks matches for each rule as results stream in
+type evaluator struct {
+	failures []ruleFailure
+}
+
+func newEvaluator(rules []failRule) *evaluator {
+	e := &evaluator{}
+	for _, r := range rules {
+		e.failures = append(e.failures, ruleFailure{rule: r})
+	}
+	return e
+}
+
+func (e *evaluator) add(res crawler.Resource) {
+	for i := range e.failures {
+		if e.failures[i].rule.match(res) {
+			e.failures[i].resources = append(e.failures[i].resources, res)
+		}
+	}
+}
+
+// failed returns the rules whose match count exceeded their limit
+func (e *evaluator) failed() []ruleFailure {
+	var out []ruleFailure
+	for _, f := range e.failures {
+		if len(f.resources) > f.rule.limit {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// exitCode returns the code of the first failing rule, or exitOK
+func (e *evaluator) exitCode() int {
+	if failed := e.failed(); len(failed) > 0 {
+		return failed[0].rule.code
+	}
+	return exitOK
+}
+
+// report prints each failing rule with its resources and every page linking to them
+func (e *evaluator) report(w io.Writer, links *crawler.LinkIndex) {
+	for _, f := range e.failed() {
+		fmt.Fprintf(w, "\nFAIL %s: %d resources", f.rule.name, len(f.resources))
+		if f.rule.limit > 0 {
+			fmt.Fprintf(w, " (limit %d)", f.rule.limit)
+		}
+		fmt.Fprintln(w)
+
+		for _, res := range f.resources {
+			fmt.Fprintf(w, "  %-10s %s\n", res.Status, res.URL)
+			refs := links.Referrers(res.URL)
+			if len(refs) == 0 && res.FromSource != "" {
+				refs = []string{res.FromSource}
+			}
+			for _, ref := range refs {
+				fmt.Fprintf(w, "             linked from %s\n", ref)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestAssertOptions_Rules(t *testing.T) {
+	tests := []struct {
+		opts    assertOptions
+		want    []string // Rule names in precedence order
+		wantErr bool
+	}{
+		{assertOptions{maxRedirectCount: -1}, nil, false},
+		{assertOptions{failOn: "4xx", maxRedirectCount: -1}, []string{"4xx"}, false},
+		{assertOptions{failOn: "4xx, 5XX,error", maxRedirectCount: -1}, []string{"error", "5xx", "4xx"}, false},
+		{assertOptions{failOn: "5xx", maxRedirectCount: 0}, []string{"5xx", "redirects"}, false},
+		{assertOptions{failOn: "3xx", maxRedirectCount: -1}, nil, true},
+	}
+	for _, tt := range tests {
+		rules, err := tt.opts.rules()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("rules() for %+v: unexpected error %v", tt.opts, err)
+			continue
+		}
+		var names []string
+		for _, r := range rules {
+			names = append(names, r.name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("rules() for %+v = %v, want %v", tt.opts, names, tt.want)
+		}
+	}
+}
+
+func TestEvaluator_ExitCode(t *testing.T) {
+	ok := crawler.Resource{URL: "https://example.com/", Status: "200"}
+	moved := crawler.Resource{URL: "https://example.com/old", Status: "301", Kind: crawler.KindRedirect}
+	missing := crawler.Resource{URL: "https://example.com/gone", Status: "404"}
+	broken := crawler.Resource{URL: "https://example.com/api", Status: "503"}
+	failed := crawler.Resource{URL: "https://example.com/slow", Status: "Error"}
+	dropped := fetchErrorResource(crawler.Event{Kind: crawler.EventError, URL: "https://example.com/reset", Err: errors.New("connection reset")})
+	all := assertOptions{failOn: "4xx,5xx,error", maxRedirectCount: 1}
+
+	tests := []struct {
+		name      string
+		opts      assertOptions
+		resources []crawler.Resource
+		want      int
+	}{
+		{"no rules", defaultAssertOptions(), []crawler.Resource{missing, broken, failed}, exitOK},
+		{"clean crawl", all, []crawler.Resource{ok, moved}, exitOK},
+		{"fetch error", all, []crawler.Resource{ok, missing, broken, failed}, exitFetchErrors},
+		{"fetch error without a row", all, []crawler.Resource{ok, dropped}, exitFetchErrors},
+		{"server error", all, []crawler.Resource{ok, missing, broken}, exitServerErrors},
+		{"client error", all, []crawler.Resource{ok, missing}, exitClientErrors},
+		{"redirects within limit", all, []crawler.Resource{ok, moved}, exitOK},
+		{"too many redirects", all, []crawler.Resource{ok, moved, moved}, exitTooManyRedirs},
+		{"unchecked status", assertOptions{failOn: "5xx", maxRedirectCount: -1}, []crawler.Resource{missing, moved}, exitOK},
+	}
+	for _, tt := range tests {
+		rules, err := tt.opts.rules()
+		if err != nil {
+			t.Fatalf("%s: rules() failed: %v", tt.name, err)
+		}
+		eval := newEvaluator(rules)
+		for _, res := range tt.resources {
+			eval.add(res)
+		}
+		if got := eval.exitCode(); got != tt.want {
+			t.Errorf("%s: exitCode() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluator_Report(t *testing.T) {
+	rules, err := assertOptions{failOn: "4xx", maxRedirectCount: -1}.rules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eval := newEvaluator(rules)
+	eval.add(crawler.Resource{URL: "https://example.com/gone", Status: "404", FromSource: "https://example.com/"})
+	eval.add(crawler.Resource{URL: "https://example.com/", Status: "200"})
+
+	var b strings.Builder
+	eval.report(&b, crawler.NewLinkIndex())
+	out := b.String()
+	for _, want := range []string{"FAIL 4xx: 1 resources", "404        https://example.com/gone", "linked from https://example.com/"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestEvaluator_MaxRedirectCountOnCrawl(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<a href="/old">old</a> <a href="/older">older</a>`))
+	})
+	mux.Handle("/old", http.RedirectHandler("/new", http.StatusMovedPermanently))
+	mux.Handle("/older", http.RedirectHandler("/new", http.StatusFound))
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<p>new</p>`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := crawler.NewStandardCrawler(crawler.NewStaticCollector(), crawler.NewInMemoryRegistry(), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results := c.Results()
+	go c.Start(ctx, ts.URL+"/")
+	var resources []crawler.Resource
+	for res := range results {
+		resources = append(resources, res)
+	}
+
+	for limit, want := range map[int]int{0: exitTooManyRedirs, 1: exitTooManyRedirs, 2: exitOK} {
+		rules, err := assertOptions{maxRedirectCount: limit}.rules()
+		if err != nil {
+			t.Fatal(err)
+		}
+		eval := newEvaluator(rules)
+		for _, res := range resources {
+			eval.add(res)
+		}
+		if got := eval.exitCode(); got != want {
+			t.Errorf("--max-redirect-count %d: exitCode() = %d, want %d (crawled %d resources)", limit, got, want, len(resources))
+		}
+	}
+}
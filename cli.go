@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitInterrupted = 130 // 128 + SIGINT, as shells report it
+)
+
+const (
+	formatText   = "text"
+	formatNDJSON = "ndjson"
+	formatJSON   = "json"
+)
+
+// runCrawl implements the non-interactive `huntsman crawl` subcommand and
+// returns the process exit code.
+func runCrawl(args []string) int {
+	opts := defaultCrawlOptions()
+	opts.mode = modeStatic
+
+	fs := flag.NewFlagSet("crawl", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  huntsman crawl [flags] <url>\n  huntsman crawl --resume <dir> [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	opts.register(fs)
+	asserts := defaultAssertOptions()
+	asserts.register(fs)
+	reportPath := fs.String("report", "", "write a Markdown (.md) or HTML (.html) report to this file when the crawl ends")
+	sitemapPath := fs.String("write-sitemap", "", "write a sitemap.xml of the crawled pages to this file when the crawl ends")
+	format := fs.String("format", formatText, "stdout format: text, ndjson (one JSON object per resource, streamed) or json (single document written when the crawl ends)")
+
+	rawUrl, err := parseArgs(fs, args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+		return exitUsage
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+		return exitUsage
+	}
+	switch *format {
+	case formatText, formatNDJSON, formatJSON:
+	default:
+		fmt.Fprintf(os.Stderr, "huntsman crawl: unknown format %q (want text, ndjson or json)\n", *format)
+		return exitUsage
+	}
+	if *reportPath != "" && !isReportPath(*reportPath) {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: --report must end in .md or .html\n")
+		return exitUsage
+	}
+	rules, err := asserts.rules()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+		return exitUsage
+	}
+
+	journal, err := opts.openJournal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+		return exitError
+	}
+	if journal != nil {
+		defer journal.Close()
+	}
+	if rawUrl == "" {
+		// The start URL may be left out when resuming
+		if journal == nil || !journal.Resumed() {
+			fs.Usage()
+			fmt.Fprintf(os.Stderr, "huntsman crawl: missing URL\n")
+			return exitUsage
+		}
+		rawUrl = journal.StartURL()
+	}
+
+	base, err := normalizeStartURL(rawUrl)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: invalid URL: %v\n", err)
+		return exitUsage
+	}
+
+	interrupted, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := opts.crawlContext(interrupted)
+	defer cancel()
+
+	links := opts.newLinkIndex()
+	sitemap := opts.newSitemap()
+	registry, closeRegistry, err := opts.newRegistry()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+		return exitError
+	}
+	defer closeRegistry()
+	frontier, err := opts.newFrontier(sitemap)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+		return exitUsage
+	}
+	c, _, err := opts.newCrawler(base, registry, frontier, links, sitemap, journal)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+		return exitUsage
+	}
+
+	// Ask for events before starting, so a resumed crawl's replayed
+	// resources aren't dropped before the loop below reads them
+	events := c.Events()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.Start(ctx, base.String())
+	}()
+
+	info := crawler.CrawlInfo{StartURL: base.String(), Mode: opts.mode, StartedAt: time.Now()}
+	summary := newCrawlSummary()
+	eval := newEvaluator(rules)
+	ndjson := crawler.NewNDJSONWriter(os.Stdout)
+	var resources []crawler.Resource
+	for e := range events {
+		if e.Kind == crawler.EventError && e.Resource == nil {
+			fmt.Fprintf(os.Stderr, "huntsman crawl: %s: %v\n", e.URL, e.Err)
+			// No row is reported, but the failure still counts for --fail-on error
+			eval.add(fetchErrorResource(e))
+		}
+		if e.Kind == crawler.EventRetried {
+			fmt.Fprintf(os.Stderr, "huntsman crawl: %s: retrying after attempt %d: %v\n", e.URL, e.Attempt, e.Err)
+		}
+		if e.Resource == nil {
+			continue
+		}
+		res := *e.Resource
+		switch *format {
+		case formatNDJSON:
+			if err := ndjson.Write(res); err != nil {
+				fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+				return exitError
+			}
+		case formatJSON:
+			// Written as a single document once the crawl ends
+		default:
+			printResource(os.Stdout, res)
+		}
+		if *format == formatJSON || *reportPath != "" || *sitemapPath != "" {
+			resources = append(resources, res)
+		}
+		summary.add(res)
+		eval.add(res)
+	}
+
+	if err := <-errc; err != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+		return exitError
+	}
+	info.FinishedAt = time.Now()
+
+	if *format == formatJSON {
+		if err := crawler.WriteJSON(os.Stdout, info, resources); err != nil {
+			fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+			return exitError
+		}
+	}
+
+	if *reportPath != "" {
+		if err := writeReport(*reportPath, info, resources, links, sitemap); err != nil {
+			fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+			return exitError
+		}
+	}
+
+	if *sitemapPath != "" {
+		if _, err := writeSitemap(*sitemapPath, base, resources); err != nil {
+			fmt.Fprintf(os.Stderr, "huntsman crawl: %v\n", err)
+			return exitError
+		}
+	}
+
+	summary.print(os.Stderr, info.FinishedAt.Sub(info.StartedAt))
+	if sitemap != nil && sitemap.Err() != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: warning: %v\n", sitemap.Err())
+	}
+	if frontier.Err() != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: warning: %v\n", frontier.Err())
+	}
+	if disk, ok := registry.(*crawler.DiskRegistry); ok && disk.Err() != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: warning: some URLs were skipped: %v\n", disk.Err())
+	}
+	if journal != nil && journal.Err() != nil {
+		fmt.Fprintf(os.Stderr, "huntsman crawl: warning: saving progress failed: %v\n", journal.Err())
+	}
+	eval.report(os.Stderr, links)
+	// Assertions on a partial crawl prove nothing either way
+	if interrupted.Err() != nil {
+		fmt.Fprintln(os.Stderr, "huntsman crawl: interrupted")
+		return exitInterrupted
+	}
+	return eval.exitCode()
+}
+
+// parseArgs parses flags that appear either before or after the URL
+// argument. The URL is "" if there is none.
+func parseArgs(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() == 0 {
+		return "", nil
+	}
+	rawUrl := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	return rawUrl, nil
+}
+
+func printResource(w io.Writer, res crawler.Resource) {
+	fmt.Fprintf(w, "%-10s %-12s %10s  %s", res.Status, res.Kind, formatSize(res.Size), res.URL)
+	if res.External {
+		fmt.Fprint(w, "  [external]")
+	}
+	if len(res.Redirects) > 0 {
+		last := res.Redirects[len(res.Redirects)-1]
+		fmt.Fprintf(w, "  → %s", last.Location)
+		if len(res.RedirectIssues) > 0 {
+			fmt.Fprintf(w, " [%s]", strings.Join(res.RedirectIssues, ", "))
+		}
+	}
+	if res.FromSource != "" {
+		fmt.Fprintf(w, "  (from %s)", res.FromSource)
+	}
+	fmt.Fprintln(w)
+}
+
+// crawlSummary tallies results for the end-of-crawl report
+type crawlSummary struct {
+	total    int
+	bytes    int64
+	byStatus map[string]int
+}
+
+func newCrawlSummary() *crawlSummary {
+	return &crawlSummary{byStatus: make(map[string]int)}
+}
+
+func (s *crawlSummary) add(res crawler.Resource) {
+	s.total++
+	s.bytes += res.Size
+	s.byStatus[res.Status]++
+}
+
+func (s *crawlSummary) print(w io.Writer, elapsed time.Duration) {
+	fmt.Fprintf(w, "\nCrawled %d resources (%s) in %s\n", s.total, formatSize(s.bytes), elapsed.Round(time.Millisecond))
+
+	statuses := make([]string, 0, len(s.byStatus))
+	for status := range s.byStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "  %-10s %d\n", status, s.byStatus[status])
+	}
+}
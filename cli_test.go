@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runCrawlCaptured runs the crawl subcommand, returning its exit code and
+// what it wrote to stdout and stderr
+func runCrawlCaptured(t *testing.T, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	capture := func(f **os.File) func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig := *f
+		*f = w
+		out := make(chan string)
+		go func() {
+			b, _ := io.ReadAll(r)
+			out <- string(b)
+		}()
+		return func() string {
+			w.Close()
+			*f = orig
+			return <-out
+		}
+	}
+	restoreStdout := capture(&os.Stdout)
+	restoreStderr := capture(&os.Stderr)
+	code = runCrawl(args)
+	return code, restoreStdout(), restoreStderr()
+}
+
+// newTestSite serves a start page linking to /a and /b
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<a href="/a">a</a> <a href="/b">b</a>`))
+		case "/a", "/b":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("ok"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantURL  string
+		wantPage int
+		wantErr  bool
+	}{
+		{[]string{"example.com"}, "example.com", 0, false},
+		{[]string{"--max-pages", "5", "example.com"}, "example.com", 5, false},
+		{[]string{"example.com", "--max-pages", "5"}, "example.com", 5, false},
+		{[]string{"--max-pages=3", "example.com", "--format", "json"}, "example.com", 3, false},
+		{[]string{}, "", 0, false},
+		{[]string{"example.com", "other.com"}, "", 0, true},
+		{[]string{"example.com", "--bogus"}, "", 0, true},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("crawl", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		opts := defaultCrawlOptions()
+		opts.register(fs)
+		fs.String("format", formatText, "")
+
+		got, err := parseArgs(fs, tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseArgs(%q): unexpected error %v", tt.args, err)
+			continue
+		}
+		if got != tt.wantURL || opts.maxPages != tt.wantPage {
+			t.Errorf("parseArgs(%q) = %q with --max-pages %d, want %q with %d", tt.args, got, opts.maxPages, tt.wantURL, tt.wantPage)
+		}
+	}
+}
+
+func TestRunCrawl_UsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"--bogus", "example.com"},
+		{"example.com", "extra"},
+		{"--format", "xml", "example.com"},
+		{"--report", "out.txt", "example.com"},
+		{"--fail-on", "3xx", "example.com"},
+		{"--max-pages", "-1", "example.com"},
+	} {
+		if code, _, _ := runCrawlCaptured(t, args...); code != exitUsage {
+			t.Errorf("runCrawl(%q) = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestRunCrawl_JSON(t *testing.T) {
+	ts := newTestSite(t)
+	code, stdout, stderr := runCrawlCaptured(t, ts.URL, "--format", "json")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+
+	var doc struct {
+		Crawl struct {
+			StartURL string `json:"start_url"`
+		} `json:"crawl"`
+		ResourceCount int `json:"resource_count"`
+		Resources     []struct {
+			URL    string `json:"url"`
+			Status string `json:"status"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &doc); err != nil {
+		t.Fatalf("Expected a single JSON document, got %v:\n%s", err, stdout)
+	}
+	if doc.ResourceCount != 3 || len(doc.Resources) != 3 {
+		t.Errorf("Expected 3 resources, got %d (%d listed)", doc.ResourceCount, len(doc.Resources))
+	}
+	if !strings.HasPrefix(doc.Crawl.StartURL, ts.URL) {
+		t.Errorf("Expected the start URL %s, got %q", ts.URL, doc.Crawl.StartURL)
+	}
+}
+
+func TestRunCrawl_NDJSON(t *testing.T) {
+	ts := newTestSite(t)
+	code, stdout, stderr := runCrawlCaptured(t, ts.URL, "--format", "ndjson")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+
+	var urls []string
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		var res struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			t.Fatalf("Expected one JSON object per line, got %v: %s", err, scanner.Text())
+		}
+		urls = append(urls, strings.TrimPrefix(res.URL, ts.URL))
+	}
+	slices.Sort(urls)
+	if want := []string{"/", "/a", "/b"}; !slices.Equal(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+}
+
+func TestRunCrawl_FailOn(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<a href="/missing">missing</a>`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	code, _, stderr := runCrawlCaptured(t, ts.URL, "--fail-on", "4xx")
+	if code != exitClientErrors {
+		t.Errorf("Expected exit code %d, got %d", exitClientErrors, code)
+	}
+	if !strings.Contains(stderr, "FAIL 4xx: 1 resources") {
+		t.Errorf("Expected the failure to be reported, got:\n%s", stderr)
+	}
+}
+
+func TestRunCrawl_Interrupted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupts can't be sent to the test process on Windows")
+	}
+	requested := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	go func() {
+		// The crawl is listening for interrupts by the time it fetches
+		<-requested
+		p, _ := os.FindProcess(os.Getpid())
+		p.Signal(os.Interrupt)
+	}()
+	code, _, stderr := runCrawlCaptured(t, ts.URL, "--fail-on", "error")
+	if code != exitInterrupted {
+		t.Errorf("Expected exit code %d, got %d", exitInterrupted, code)
+	}
+	if !strings.Contains(stderr, "interrupted") {
+		t.Errorf("Expected the interruption to be reported, got:\n%s", stderr)
+	}
+}
@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+// BloomRegistry implements Registry with a Bloom filter, using a fixed amount
+// of memory however long URLs are. It never reports a new URL as visited
+// more than once, but may report one as already visited, and so skip it,
+// with roughly the false positive rate it was sized for.
+type BloomRegistry struct {
+	mu    sync.Mutex
+	bits  []uint64
+	m     uint64 // Number of bits
+	k     int    // Number of hash functions
+	seed1 maphash.Seed
+	seed2 maphash.Seed
+}
+
+// NewBloomRegistry sizes a BloomRegistry to hold capacity URLs with the given
+// false positive rate, such as 0.001. Past capacity the rate rises.
+func NewBloomRegistry(capacity int, falsePositiveRate float64) (*BloomRegistry, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("bloom filter capacity must be positive")
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("bloom filter false positive rate must be between 0 and 1")
+	}
+
+	n := float64(capacity)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := int(math.Max(1, math.Round(m/n*math.Ln2)))
+	words := (uint64(m) + 63) / 64
+	return &BloomRegistry{
+		bits:  make([]uint64, words),
+		m:     words * 64,
+		k:     k,
+		seed1: maphash.MakeSeed(),
+		seed2: maphash.MakeSeed(),
+	}, nil
+}
+
+// Visit marks a URL as visited. Returns true if it was visited for the first
+// time, or false if it was (or appears to have been) visited already.
+func (r *BloomRegistry) Visit(u string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	first := false
+	r.each(u, func(word int, mask uint64) {
+		if r.bits[word]&mask == 0 {
+			r.bits[word] |= mask
+			first = true
+		}
+	})
+	return first
+}
+
+// IsVisited checks if a URL has (or appears to have) been visited.
+func (r *BloomRegistry) IsVisited(u string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	visited := true
+	r.each(u, func(word int, mask uint64) {
+		if r.bits[word]&mask == 0 {
+			visited = false
+		}
+	})
+	return visited
+}
+
+// each calls f with the word index and bit mask of each of u's k bits,
+// derived from two hashes by double hashing
+func (r *BloomRegistry) each(u string, f func(word int, mask uint64)) {
+	h1 := maphash.String(r.seed1, u)
+	h2 := maphash.String(r.seed2, u) | 1
+	for i := range r.k {
+		bit := (h1 + uint64(i)*h2) % r.m
+		f(int(bit/64), 1<<(bit%64))
+	}
+}
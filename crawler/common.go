@@ -1,6 +1,10 @@
 package crawler
 
-import "strings"
+import (
+	"context"
+	"net/http"
+	"strings"
+)
 
 // DetermineKind infers the resource kind from the Content-Type header
 func DetermineKind(contentType string) string {
@@ -27,3 +31,71 @@ func DetermineKind(contentType string) string {
 	}
 	return "Other"
 }
+
+// contentEncoding returns the response's Content-Encoding, including gzip
+// that the transport already decoded transparently
+func contentEncoding(resp *http.Response) string {
+	if resp.Uncompressed {
+		return "gzip"
+	}
+	return resp.Header.Get("Content-Encoding")
+}
+
+// userAgentKey is the context key for the User-Agent collectors send
+type userAgentKey struct{}
+
+// withUserAgent returns a context that makes collectors send userAgent
+func withUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, userAgent)
+}
+
+// userAgentFrom returns the User-Agent carried by ctx, or DefaultUserAgent
+func userAgentFrom(ctx context.Context) string {
+	if ua, ok := ctx.Value(userAgentKey{}).(string); ok && ua != "" {
+		return ua
+	}
+	return DefaultUserAgent
+}
+
+// valuedRobotsDirectives are the X-Robots-Tag directives written as
+// "name: value", which mustn't be mistaken for a user agent
+var valuedRobotsDirectives = map[string]bool{
+	"unavailable_after": true,
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+}
+
+// robotsTagDirectives returns the directives in X-Robots-Tag header values
+// that apply to userAgent. A header may scope directives to an agent, as in
+// "googlebot: noindex, nofollow"; the scope runs to the next agent or the end
+// of the header. Agents are matched like robots.txt groups.
+func robotsTagDirectives(values []string, userAgent string) []string {
+	ua := strings.ToLower(userAgent)
+	if token, _, ok := strings.Cut(ua, "/"); ok {
+		ua = token
+	}
+
+	var kept []string
+	for _, v := range values {
+		applies := true
+		var directives []string
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if agent, rest, ok := strings.Cut(d, ":"); ok {
+				agent = strings.ToLower(strings.TrimSpace(agent))
+				if !valuedRobotsDirectives[agent] {
+					applies = agent != "" && strings.Contains(ua, agent)
+					d = strings.TrimSpace(rest)
+				}
+			}
+			if applies && d != "" {
+				directives = append(directives, d)
+			}
+		}
+		if len(directives) > 0 {
+			kept = append(kept, strings.Join(directives, ", "))
+		}
+	}
+	return kept
+}
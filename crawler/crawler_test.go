@@ -2,6 +2,8 @@ package crawler_test
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -85,3 +87,306 @@ func TestStandardCrawler_FollowsLinks(t *testing.T) {
 		t.Errorf("Expected 2 results, got %d", count)
 	}
 }
+
+func TestStandardCrawler_MaxDepth(t *testing.T) {
+	collector := &MockCollectorWithLinks{
+		Links: map[string][]string{
+			"http://example.com/":  {"http://example.com/a"},
+			"http://example.com/a": {"http://example.com/b"},
+			"http://example.com/b": {"http://example.com/c"},
+		},
+	}
+	registry := crawler.NewInMemoryRegistry()
+
+	c := crawler.NewStandardCrawler(collector, registry, 2, crawler.WithMaxDepth(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go c.Start(ctx, "http://example.com/")
+
+	depths := make(map[string]int)
+	for res := range c.Results() {
+		depths[res.URL] = res.Depth
+	}
+
+	if len(depths) != 2 {
+		t.Fatalf("Expected 2 results, got %d: %v", len(depths), depths)
+	}
+	if depths["http://example.com/"] != 0 {
+		t.Errorf("Expected start URL at depth 0, got %d", depths["http://example.com/"])
+	}
+	if depths["http://example.com/a"] != 1 {
+		t.Errorf("Expected /a at depth 1, got %d", depths["http://example.com/a"])
+	}
+}
+
+func TestStandardCrawler_MaxPages(t *testing.T) {
+	collector := &MockCollectorWithLinks{
+		Links: map[string][]string{
+			"http://example.com/": {
+				"http://example.com/1",
+				"http://example.com/2",
+				"http://example.com/3",
+				"http://example.com/4",
+			},
+		},
+	}
+	registry := crawler.NewInMemoryRegistry()
+
+	c := crawler.NewStandardCrawler(collector, registry, 2, crawler.WithMaxPages(3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go c.Start(ctx, "http://example.com/")
+
+	count := 0
+	for range c.Results() {
+		count++
+	}
+
+	if count != 3 {
+		t.Errorf("Expected 3 results, got %d", count)
+	}
+}
+
+func TestStandardCrawler_MaxDuration(t *testing.T) {
+	collector := &SlowCollector{Delay: time.Second}
+	registry := crawler.NewInMemoryRegistry()
+
+	c := crawler.NewStandardCrawler(collector, registry, 1, crawler.WithMaxDuration(50*time.Millisecond))
+
+	done := make(chan error)
+	go func() {
+		done <- c.Start(context.Background(), "http://example.com/")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return after max duration")
+	}
+}
+
+type SlowCollector struct {
+	Delay time.Duration
+}
+
+func (s *SlowCollector) Collect(ctx context.Context, targetURL string) (*crawler.Resource, error) {
+	select {
+	case <-time.After(s.Delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &crawler.Resource{URL: targetURL, Status: "200"}, nil
+}
+
+func TestStandardCrawler_StopBeforeStart(t *testing.T) {
+	c := crawler.NewStandardCrawler(&SlowCollector{Delay: time.Second}, crawler.NewInMemoryRegistry(), 1)
+	c.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	begin := time.Now()
+	if err := c.Start(ctx, "http://example.com/"); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected a stopped crawler to return at once, took %v", elapsed)
+	}
+}
+
+func TestStandardCrawler_RecordsReferrers(t *testing.T) {
+	collector := &MockCollectorWithLinks{
+		Links: map[string][]string{
+			"http://example.com/":  {"http://example.com/a", "http://example.com/b"},
+			"http://example.com/a": {"http://example.com/b"},
+		},
+	}
+	registry := crawler.NewInMemoryRegistry()
+
+	c := crawler.NewStandardCrawler(collector, registry, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go c.Start(ctx, "http://example.com/")
+
+	from := make(map[string]string)
+	for res := range c.Results() {
+		from[res.URL] = res.FromSource
+	}
+
+	if from["http://example.com/"] != "" {
+		t.Errorf("Expected no referrer for start URL, got %s", from["http://example.com/"])
+	}
+	if from["http://example.com/a"] != "http://example.com/" {
+		t.Errorf("Expected /a referrer to be start URL, got %s", from["http://example.com/a"])
+	}
+
+	refs := c.Links().Referrers("http://example.com/b")
+	if len(refs) != 2 {
+		t.Fatalf("Expected 2 referrers for /b, got %v", refs)
+	}
+}
+
+func TestStandardCrawler_WithoutLinkIndex(t *testing.T) {
+	collector := &MockCollectorWithLinks{
+		Links: map[string][]string{
+			"http://example.com/": {"http://example.com/a"},
+		},
+	}
+	c := crawler.NewStandardCrawler(collector, crawler.NewInMemoryRegistry(), 1, crawler.WithLinkIndex(nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go c.Start(ctx, "http://example.com/")
+
+	count := 0
+	for range c.Results() {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 results, got %d", count)
+	}
+	if c.Links() != nil {
+		t.Error("Expected no link index")
+	}
+	if refs := c.Links().Referrers("http://example.com/a"); len(refs) != 0 {
+		t.Errorf("Expected no referrers from a nil index, got %v", refs)
+	}
+}
+
+type RecordingCollector struct {
+	mu   sync.Mutex
+	URLs []string
+}
+
+func (r *RecordingCollector) Collect(ctx context.Context, targetURL string) (*crawler.Resource, error) {
+	r.mu.Lock()
+	r.URLs = append(r.URLs, targetURL)
+	r.mu.Unlock()
+	return &crawler.Resource{URL: targetURL, Status: "404", Links: []string{"http://other.com/deeper"}}, nil
+}
+
+func TestStandardCrawler_ExternalLinks(t *testing.T) {
+	collector := &MockCollectorWithLinks{
+		Links: map[string][]string{
+			"http://example.com/":  {"http://other.com/dead", "http://example.com/a", "mailto:someone@example.com"},
+			"http://example.com/a": {"http://other.com/dead"},
+		},
+	}
+	checker := &RecordingCollector{}
+
+	c := crawler.NewStandardCrawler(collector, crawler.NewInMemoryRegistry(), 2, crawler.WithExternalLinks(checker))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go c.Start(ctx, "http://example.com/")
+
+	results := make(map[string]crawler.Resource)
+	for res := range c.Results() {
+		results[res.URL] = res
+	}
+
+	if len(checker.URLs) != 1 || checker.URLs[0] != "http://other.com/dead" {
+		t.Errorf("Expected the external link to be checked exactly once, got %v", checker.URLs)
+	}
+	if !results["http://other.com/dead"].External {
+		t.Error("Expected the external result to be tagged as external")
+	}
+	if results["http://example.com/a"].External {
+		t.Error("Expected in-scope results not to be tagged as external")
+	}
+	if _, ok := results["http://other.com/deeper"]; ok {
+		t.Error("Expected links on external pages not to be followed")
+	}
+}
+
+type ErrorCollector struct{}
+
+func (e *ErrorCollector) Collect(ctx context.Context, targetURL string) (*crawler.Resource, error) {
+	return &crawler.Resource{URL: targetURL, Status: "Error", Kind: "N/A"}, errors.New("connection refused")
+}
+
+func TestStandardCrawler_RecordsCollectErrors(t *testing.T) {
+	c := crawler.NewStandardCrawler(&ErrorCollector{}, crawler.NewInMemoryRegistry(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go c.Start(ctx, "http://example.com/")
+
+	var results []crawler.Resource
+	for res := range c.Results() {
+		results = append(results, res)
+	}
+
+	if len(results) != 1 || results[0].Error != "connection refused" {
+		t.Errorf("Expected the collect error on the result, got %v", results)
+	}
+}
+
+// GatedCollector blocks each Collect until a value is sent on Release,
+// reporting each URL on Started first
+type GatedCollector struct {
+	Links   map[string][]string
+	Started chan string
+	Release chan struct{}
+}
+
+func (g *GatedCollector) Collect(ctx context.Context, targetURL string) (*crawler.Resource, error) {
+	g.Started <- targetURL
+	<-g.Release
+	return &crawler.Resource{URL: targetURL, Status: "200", Links: g.Links[targetURL]}, nil
+}
+
+func TestStandardCrawler_PauseResume(t *testing.T) {
+	collector := &GatedCollector{
+		Links: map[string][]string{
+			"http://example.com/": {"http://example.com/a", "http://example.com/b"},
+		},
+		Started: make(chan string, 10),
+		Release: make(chan struct{}),
+	}
+	c := crawler.NewStandardCrawler(collector, crawler.NewInMemoryRegistry(), 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go c.Start(ctx, "http://example.com/")
+
+	// Pause while the start page is in flight; it should still be reported
+	<-collector.Started
+	c.Pause()
+	collector.Release <- struct{}{}
+	if res := <-c.Results(); res.URL != "http://example.com/" {
+		t.Fatalf("Expected the in-flight page to be reported, got %s", res.URL)
+	}
+
+	select {
+	case u := <-collector.Started:
+		t.Fatalf("Expected no new requests while paused, got %s", u)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	c.Resume()
+	go func() {
+		for range collector.Started {
+			collector.Release <- struct{}{}
+		}
+	}()
+
+	count := 1
+	for range c.Results() {
+		count++
+	}
+	close(collector.Started)
+	if count != 3 {
+		t.Errorf("Expected the queued links to be crawled after resuming, got %d results", count)
+	}
+}
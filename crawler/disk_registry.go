@@ -0,0 +1,100 @@
+package crawler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var visitedBucket = []byte("visited")
+
+// DiskRegistry implements Registry in an embedded key-value store on disk,
+// so memory use stays flat however many URLs are visited. Writes aren't
+// synced; the file is a working set rather than a durable record.
+type DiskRegistry struct {
+	db *bolt.DB
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewDiskRegistry opens or creates a registry stored in the file at path.
+// URLs recorded in an existing file count as visited.
+func NewDiskRegistry(path string) (*DiskRegistry, error) {
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second, NoFreelistSync: true})
+	if err != nil {
+		return nil, err
+	}
+	db.NoSync = true
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(visitedBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &DiskRegistry{db: db}, nil
+}
+
+// Visit marks a URL as visited. Returns true if it was visited for the first
+// time. A URL that can't be recorded is reported as already visited so that
+// storage errors never cause a URL to be crawled twice; see Err.
+func (r *DiskRegistry) Visit(u string) bool {
+	if r.IsVisited(u) {
+		return false
+	}
+	first := false
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(visitedBucket)
+		if b.Get([]byte(u)) != nil {
+			return nil
+		}
+		if err := b.Put([]byte(u), []byte{1}); err != nil {
+			return err
+		}
+		first = true
+		return nil
+	})
+	if err != nil {
+		r.fail(fmt.Errorf("recording visited URL %q: %w", u, err))
+	}
+	return first
+}
+
+// IsVisited checks if a URL has been visited.
+func (r *DiskRegistry) IsVisited(u string) bool {
+	visited := false
+	err := r.db.View(func(tx *bolt.Tx) error {
+		visited = tx.Bucket(visitedBucket).Get([]byte(u)) != nil
+		return nil
+	})
+	if err != nil {
+		r.fail(fmt.Errorf("looking up visited URL %q: %w", u, err))
+	}
+	return visited
+}
+
+// Err returns the first error reading or writing the store. URLs affected by
+// it were skipped rather than crawled.
+func (r *DiskRegistry) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// fail records err unless an earlier error has been
+func (r *DiskRegistry) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Close closes the underlying file
+func (r *DiskRegistry) Close() error {
+	return r.db.Close()
+}
@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"context"
+	"time"
+)
+
+// EventKind identifies what happened in an Event
+type EventKind string
+
+const (
+	// EventQueued is sent when a URL is added to the frontier
+	EventQueued EventKind = "queued"
+	// EventStarted is sent when a worker starts fetching a URL
+	EventStarted EventKind = "started"
+	// EventCompleted carries a resource that was fetched and reported
+	EventCompleted EventKind = "completed"
+	// EventRetried is sent before a RetryCollector tries a URL again
+	EventRetried EventKind = "retried"
+	// EventSkipped is sent when the crawler decides not to fetch a URL, or
+	// not to follow its links. Reason says why.
+	EventSkipped EventKind = "skipped"
+	// EventError is sent when a URL couldn't be fetched. Resource is set
+	// if the collector returned a partial result.
+	EventError EventKind = "error"
+	// EventFinished is the last event of a crawl. Err is set if the crawl
+	// couldn't run.
+	EventFinished EventKind = "finished"
+)
+
+// SkipReason explains an EventSkipped
+type SkipReason string
+
+const (
+	// SkipRobots means robots.txt disallows the URL. It is reported with
+	// StatusBlockedByRobots instead of being fetched.
+	SkipRobots SkipReason = "robots"
+	// SkipBudget means the URL was queued but WithMaxPages had been reached
+	SkipBudget SkipReason = "budget"
+	// SkipNoFollow means the page's links aren't followed because it is
+	// marked nofollow
+	SkipNoFollow SkipReason = "nofollow"
+	// SkipDepth means the page's links aren't followed because it is at
+	// WithMaxDepth
+	SkipDepth SkipReason = "depth"
+)
+
+// Stats is a snapshot of a crawl's progress
+type Stats struct {
+	Queued   int // URLs waiting in the frontier
+	InFlight int // URLs being fetched
+	Crawled  int // Resources reported, including errors
+	Errors   int
+	Retries  int
+	Skipped  int
+	Elapsed  time.Duration
+}
+
+// Event is a step in a crawl, sent on Crawler.Events
+type Event struct {
+	Kind     EventKind
+	URL      string
+	Resource *Resource // Completed, and Skipped or Error when there is a row to report
+	Reason   SkipReason
+	Attempt  int // Retried: the attempt that failed, from 1
+	Err      error
+	Stats    Stats // As of the event
+}
+
+// retryHookKey is the context key for the function RetryCollector calls
+// before each retry
+type retryHookKey struct{}
+
+// withRetryHook returns a context that makes RetryCollector call hook
+// before it retries
+func withRetryHook(ctx context.Context, hook func(attempt int, err error)) context.Context {
+	return context.WithValue(ctx, retryHookKey{}, hook)
+}
+
+// notifyRetry calls the retry hook carried by ctx, if any
+func notifyRetry(ctx context.Context, attempt int, err error) {
+	if hook, ok := ctx.Value(retryHookKey{}).(func(int, error)); ok {
+		hook(attempt, err)
+	}
+}
@@ -0,0 +1,153 @@
+package crawler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+// FlakyCollector serves Links but fails the first Failures[url] attempts at
+// each URL, without returning a resource
+type FlakyCollector struct {
+	Links    map[string][]string
+	Failures map[string]int
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func (f *FlakyCollector) Collect(ctx context.Context, targetURL string) (*crawler.Resource, error) {
+	f.mu.Lock()
+	if f.attempts == nil {
+		f.attempts = make(map[string]int)
+	}
+	f.attempts[targetURL]++
+	failed := f.attempts[targetURL] <= f.Failures[targetURL]
+	f.mu.Unlock()
+	if failed {
+		return nil, errors.New("connection reset")
+	}
+	return &crawler.Resource{URL: targetURL, Status: "200", Links: f.Links[targetURL]}, nil
+}
+
+func TestStandardCrawler_Events(t *testing.T) {
+	collector := &FlakyCollector{
+		Links: map[string][]string{
+			"http://example.com/": {"http://example.com/a", "http://example.com/b", "http://example.com/c", "http://example.com/d"},
+		},
+		Failures: map[string]int{"http://example.com/a": 1, "http://example.com/c": 99},
+	}
+	retrying := crawler.NewRetryCollector(collector, 1, time.Millisecond)
+	c := crawler.NewStandardCrawler(retrying, crawler.NewInMemoryRegistry(), 1, crawler.WithMaxPages(4))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go c.Start(ctx, "http://example.com/")
+
+	counts := make(map[crawler.EventKind]int)
+	var last crawler.Event
+	for e := range c.Events() {
+		if last.Kind == crawler.EventFinished {
+			t.Fatalf("Got %s after the finished event", e.Kind)
+		}
+		counts[e.Kind]++
+		switch e.Kind {
+		case crawler.EventSkipped:
+			if e.URL != "http://example.com/d" || e.Reason != crawler.SkipBudget {
+				t.Errorf("Expected /d to be skipped for the budget, got %s for %s", e.Reason, e.URL)
+			}
+		case crawler.EventError:
+			if e.URL != "http://example.com/c" || e.Err == nil {
+				t.Errorf("Expected an error for /c, got %v for %s", e.Err, e.URL)
+			}
+		case crawler.EventRetried:
+			if e.Attempt != 1 {
+				t.Errorf("Expected retries after the first attempt, got attempt %d", e.Attempt)
+			}
+		}
+		last = e
+	}
+
+	want := map[crawler.EventKind]int{
+		crawler.EventQueued:    5,
+		crawler.EventStarted:   4,
+		crawler.EventCompleted: 3,
+		crawler.EventRetried:   2,
+		crawler.EventError:     1,
+		crawler.EventSkipped:   1,
+		crawler.EventFinished:  1,
+	}
+	for kind, n := range want {
+		if counts[kind] != n {
+			t.Errorf("Expected %d %s events, got %d", n, kind, counts[kind])
+		}
+	}
+
+	wantStats := crawler.Stats{Crawled: 4, Errors: 1, Retries: 2, Skipped: 1}
+	got := last.Stats
+	got.Elapsed = 0
+	if got != wantStats {
+		t.Errorf("Expected final stats %+v, got %+v", wantStats, got)
+	}
+}
+
+func TestStandardCrawler_EventsAndResults(t *testing.T) {
+	collector := &MockCollectorWithLinks{
+		Links: map[string][]string{
+			"http://example.com/": {"http://example.com/a", "http://example.com/b"},
+		},
+	}
+	c := crawler.NewStandardCrawler(collector, crawler.NewInMemoryRegistry(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	events, results := c.Events(), c.Results()
+	errc := make(chan error, 1)
+	go func() { errc <- c.Start(ctx, "http://example.com/") }()
+
+	// Both streams carry every resource. They are read concurrently, since
+	// the crawl waits on whichever one falls behind.
+	var fromResults, fromEvents int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range results {
+			fromResults++
+		}
+	}()
+	for e := range events {
+		if e.Resource != nil {
+			fromEvents++
+		}
+	}
+	<-done
+	if fromResults != 3 || fromEvents != 3 {
+		t.Errorf("Expected 3 resources on each stream, got %d results and %d events", fromResults, fromEvents)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("Start failed: %v", err)
+	}
+}
+
+func TestStandardCrawler_UnreadStreams(t *testing.T) {
+	links := make([]string, 300)
+	for i := range links {
+		links[i] = fmt.Sprintf("http://example.com/%d", i)
+	}
+	collector := &MockCollectorWithLinks{Links: map[string][]string{"http://example.com/": links}}
+	c := crawler.NewStandardCrawler(collector, crawler.NewInMemoryRegistry(), 4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.Start(ctx, "http://example.com/"); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("Expected the crawl to finish without anyone reading its events")
+	}
+}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// CrawlInfo describes a crawl as a whole for exported reports
+type CrawlInfo struct {
+	StartURL   string    `json:"start_url"`
+	Mode       string    `json:"mode,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// jsonExport is the document written by WriteJSON
+type jsonExport struct {
+	Crawl         CrawlInfo  `json:"crawl"`
+	ResourceCount int        `json:"resource_count"`
+	Resources     []Resource `json:"resources"`
+}
+
+// WriteJSON writes info and every resource to w as a single indented JSON document
+func WriteJSON(w io.Writer, info CrawlInfo, resources []Resource) error {
+	if resources == nil {
+		resources = []Resource{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonExport{
+		Crawl:         info,
+		ResourceCount: len(resources),
+		Resources:     resources,
+	})
+}
+
+// NDJSONWriter streams resources as newline-delimited JSON, one object per line,
+// so large crawls never need to be held in memory
+type NDJSONWriter struct {
+	enc *json.Encoder
+}
+
+// NewNDJSONWriter creates an NDJSONWriter that writes to w
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{enc: json.NewEncoder(w)}
+}
+
+// Write encodes res as a single line
+func (n *NDJSONWriter) Write(res Resource) error {
+	return n.enc.Encode(res)
+}
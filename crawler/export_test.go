@@ -0,0 +1,67 @@
+package crawler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	info := crawler.CrawlInfo{StartURL: "http://example.com", Mode: "static"}
+	resources := []crawler.Resource{
+		{URL: "http://example.com", Status: "200", Kind: "document", Size: 12345, Links: []string{"http://example.com/a"}},
+	}
+
+	if err := crawler.WriteJSON(&buf, info, resources); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var doc struct {
+		Crawl struct {
+			StartURL string `json:"start_url"`
+		} `json:"crawl"`
+		ResourceCount int                `json:"resource_count"`
+		Resources     []crawler.Resource `json:"resources"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	if doc.Crawl.StartURL != "http://example.com" {
+		t.Errorf("Expected start URL http://example.com, got %s", doc.Crawl.StartURL)
+	}
+	if doc.ResourceCount != 1 || len(doc.Resources) != 1 {
+		t.Fatalf("Expected 1 resource, got %d", len(doc.Resources))
+	}
+	if doc.Resources[0].Size != 12345 {
+		t.Errorf("Expected raw size 12345, got %d", doc.Resources[0].Size)
+	}
+	if len(doc.Resources[0].Links) != 1 {
+		t.Errorf("Expected outgoing links to be exported, got %v", doc.Resources[0].Links)
+	}
+}
+
+func TestNDJSONWriter_Write(t *testing.T) {
+	var buf bytes.Buffer
+	w := crawler.NewNDJSONWriter(&buf)
+
+	w.Write(crawler.Resource{URL: "http://example.com/a", Status: "200"})
+	w.Write(crawler.Resource{URL: "http://example.com/b", Status: "404"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+
+	var res crawler.Resource
+	if err := json.Unmarshal([]byte(lines[1]), &res); err != nil {
+		t.Fatalf("Invalid JSON line: %v", err)
+	}
+	if res.Status != "404" {
+		t.Errorf("Expected status 404, got %s", res.Status)
+	}
+}
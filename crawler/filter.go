@@ -0,0 +1,499 @@
+package crawler
+
+import (
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter is a parsed result filter query. A query is a list of terms, all of
+// which must match. Terms are:
+//
+//	text                 URL contains text
+//	/regexp/             URL matches the regular expression
+//	url:text             URL contains text (or url:/regexp/)
+//	type:text            Kind contains text; type:image matches every image kind
+//	status:404           Status is exactly 404; status:40 matches codes
+//	                     starting 40, status:4xx and status:>=400 match ranges
+//	                     and status:err matches labels like Error
+//	size:>500kb          Size compared in b, kb, mb or gb (1 kb = 1024 bytes)
+//	depth:<=2            Depth compared as a number
+//	from:text            Any referrer contains text (or from:/regexp/)
+//	external:yes         External is set (or no)
+//	header:name          The response header is present
+//	header:name=text     The header's value contains text (or name=/regexp/)
+//
+// Prefix a term with - to negate it, join terms with OR (or |) to match
+// either side, and group terms with parentheses. Values may be quoted to
+// include spaces. Plain text matches ignore case; regular expressions are
+// matched as written.
+type Filter struct {
+	match predicate
+}
+
+// filterTarget is what a predicate is evaluated against
+type filterTarget struct {
+	res   *Resource
+	links *LinkIndex
+	refs  []string
+	found bool
+}
+
+// referrers returns every known page linking to the resource, falling back to
+// the page it was found on
+func (t *filterTarget) referrers() []string {
+	if !t.found {
+		t.found = true
+		if t.links != nil {
+			t.refs = t.links.Referrers(t.res.URL)
+		}
+		if len(t.refs) == 0 && t.res.FromSource != "" {
+			t.refs = []string{t.res.FromSource}
+		}
+	}
+	return t.refs
+}
+
+type predicate func(t *filterTarget) bool
+
+// Match reports whether res passes the filter. Referrers for from: terms are
+// looked up in links when it is not nil.
+func (f *Filter) Match(res Resource, links *LinkIndex) bool {
+	if f == nil || f.match == nil {
+		return true
+	}
+	return f.match(&filterTarget{res: &res, links: links})
+}
+
+// ParseFilter parses a filter query. An empty query matches everything.
+func ParseFilter(query string) (*Filter, error) {
+	tokens, err := lexFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	if len(tokens) == 0 {
+		return &Filter{}, nil
+	}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q", tok.text)
+	}
+	return &Filter{match: match}, nil
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokOpen
+	tokClose
+	tokNot
+	tokOr
+)
+
+type filterToken struct {
+	kind tokenKind
+	text string
+	// quoted is set when any part of a word was quoted, so it is never read
+	// as a regular expression
+	quoted bool
+}
+
+// lexFilter splits a query into words, parentheses, negations and ORs.
+// Quotes may appear anywhere in a word and a /regexp/ after a field (or at
+// the start of a word) runs to its closing slash, so either can contain
+// spaces or parentheses.
+func lexFilter(query string) ([]filterToken, error) {
+	var tokens []filterToken
+	rs := []rune(query)
+	for i := 0; i < len(rs); {
+		switch r := rs[i]; {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokOpen, text: "("})
+			i++
+			continue
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokClose, text: ")"})
+			i++
+			continue
+		case r == '-' && i+1 < len(rs) && !unicode.IsSpace(rs[i+1]):
+			tokens = append(tokens, filterToken{kind: tokNot, text: "-"})
+			i++
+			continue
+		}
+
+		var b strings.Builder
+		tok := filterToken{kind: tokWord}
+	word:
+		for i < len(rs) {
+			r := rs[i]
+			switch {
+			case unicode.IsSpace(r) || r == ')':
+				break word
+			case r == '"':
+				end := i + 1
+				for end < len(rs) && rs[end] != '"' {
+					if rs[end] == '\\' && end+1 < len(rs) {
+						end++
+					}
+					b.WriteRune(rs[end])
+					end++
+				}
+				if end >= len(rs) {
+					return nil, fmt.Errorf("missing closing quote")
+				}
+				tok.quoted = true
+				i = end + 1
+			case r == '/' && (b.Len() == 0 || strings.HasSuffix(b.String(), ":") || strings.HasSuffix(b.String(), "=")):
+				end := i + 1
+				for end < len(rs) && rs[end] != '/' {
+					if rs[end] == '\\' && end+1 < len(rs) {
+						end++
+					}
+					end++
+				}
+				if end >= len(rs) {
+					// A lone slash is plain text, like a path prefix
+					b.WriteString(string(rs[i:]))
+					i = len(rs)
+					break word
+				}
+				b.WriteString(string(rs[i : end+1]))
+				i = end + 1
+			default:
+				b.WriteRune(r)
+				i++
+			}
+		}
+		tok.text = b.String()
+		if !tok.quoted && (tok.text == "OR" || tok.text == "|") {
+			tok.kind = tokOr
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() (filterToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *filterParser) parseOr() (predicate, error) {
+	var alts []predicate
+	for {
+		and, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, and)
+		if tok, ok := p.peek(); !ok || tok.kind != tokOr {
+			break
+		}
+		p.pos++
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return func(t *filterTarget) bool {
+		for _, alt := range alts {
+			if alt(t) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func (p *filterParser) parseAnd() (predicate, error) {
+	var terms []predicate
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokOr || tok.kind == tokClose {
+			break
+		}
+		term, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("expected a term")
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return func(t *filterTarget) bool {
+		for _, term := range terms {
+			if !term(t) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func (p *filterParser) parseUnary() (predicate, error) {
+	tok, _ := p.peek()
+	p.pos++
+	switch tok.kind {
+	case tokNot:
+		if next, ok := p.peek(); !ok || next.kind == tokClose || next.kind == tokOr {
+			return nil, fmt.Errorf("expected a term after -")
+		}
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(t *filterTarget) bool { return !inner(t) }, nil
+	case tokOpen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, ok := p.peek(); !ok || tok.kind != tokClose {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	}
+	return parseTerm(tok)
+}
+
+// parseTerm builds the predicate for a single field:value word
+func parseTerm(tok filterToken) (predicate, error) {
+	field, value, found := strings.Cut(tok.text, ":")
+	field = strings.ToLower(field)
+	if !found || !isFilterField(field) {
+		field, value = "url", tok.text
+	}
+
+	switch field {
+	case "url":
+		m, err := textMatcher(value, tok.quoted)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *filterTarget) bool { return m(t.res.URL) }, nil
+	case "type":
+		m, err := textMatcher(value, tok.quoted)
+		if err != nil {
+			return nil, err
+		}
+		kinds := kindAliases[strings.ToLower(value)]
+		if tok.quoted {
+			kinds = nil
+		}
+		return func(t *filterTarget) bool {
+			return m(t.res.Kind) || slices.Contains(kinds, strings.ToLower(t.res.Kind))
+		}, nil
+	case "from":
+		m, err := textMatcher(value, tok.quoted)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *filterTarget) bool {
+			for _, ref := range t.referrers() {
+				if m(ref) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "status":
+		return statusMatcher(value)
+	case "size":
+		op, n, err := parseComparison(value, parseSize)
+		if err != nil {
+			return nil, fmt.Errorf("size: %w", err)
+		}
+		return func(t *filterTarget) bool { return op(t.res.Size, n) }, nil
+	case "depth":
+		op, n, err := parseComparison(value, func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, 64)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("depth: %w", err)
+		}
+		return func(t *filterTarget) bool { return op(int64(t.res.Depth), n) }, nil
+	case "external":
+		var want bool
+		switch strings.ToLower(value) {
+		case "yes", "true":
+			want = true
+		case "no", "false":
+		default:
+			return nil, fmt.Errorf("external: expected yes or no, got %q", value)
+		}
+		return func(t *filterTarget) bool { return t.res.External == want }, nil
+	case "header":
+		name, pattern, hasValue := strings.Cut(value, "=")
+		if name == "" {
+			return nil, fmt.Errorf("header: missing header name")
+		}
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if !hasValue {
+			return func(t *filterTarget) bool {
+				_, ok := t.res.Headers[name]
+				return ok
+			}, nil
+		}
+		m, err := textMatcher(pattern, tok.quoted)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *filterTarget) bool {
+			for _, v := range t.res.Headers[name] {
+				if m(v) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown field %q", field)
+}
+
+// kindAliases maps type: categories to the Kinds DetermineKind reports for them
+var kindAliases = map[string][]string{
+	"image": {"png", "jpeg", "gif", "svg+xml", "x-icon"},
+}
+
+func isFilterField(field string) bool {
+	switch field {
+	case "url", "type", "status", "size", "depth", "from", "external", "header":
+		return true
+	}
+	return false
+}
+
+// textMatcher matches a /regexp/, unless quoted, or else a case-insensitive
+// substring
+func textMatcher(value string, quoted bool) (func(string) bool, error) {
+	if !quoted && len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		re, err := regexp.Compile(value[1 : len(value)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp %s: %w", value, err)
+		}
+		return re.MatchString, nil
+	}
+	value = strings.ToLower(value)
+	return func(s string) bool {
+		return strings.Contains(strings.ToLower(s), value)
+	}, nil
+}
+
+var statusClass = regexp.MustCompile(`^[0-9][0-9x][0-9x]$`)
+
+// statusMatcher matches an exact code, a code prefix like 40, a class like
+// 4xx, a comparison like >=400, or a label like error
+func statusMatcher(value string) (predicate, error) {
+	value = strings.ToLower(value)
+	switch {
+	case value == "":
+		return nil, fmt.Errorf("status: missing value")
+	case statusClass.MatchString(value):
+		return func(t *filterTarget) bool {
+			status := t.res.Status
+			if len(status) != 3 {
+				return false
+			}
+			for i := range 3 {
+				if value[i] != 'x' && value[i] != status[i] {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case strings.ContainsAny(value[:1], "<>="):
+		op, n, err := parseComparison(value, func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, 64)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("status: %w", err)
+		}
+		return func(t *filterTarget) bool {
+			code, err := strconv.ParseInt(t.res.Status, 10, 64)
+			return err == nil && op(code, n)
+		}, nil
+	case strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' }) < 0:
+		if len(value) > 3 {
+			return nil, fmt.Errorf("status: unknown status %q", value)
+		}
+		return func(t *filterTarget) bool {
+			return len(t.res.Status) == 3 && strings.HasPrefix(t.res.Status, value)
+		}, nil
+	}
+	return func(t *filterTarget) bool {
+		return strings.Contains(strings.ToLower(t.res.Status), value)
+	}, nil
+}
+
+// parseComparison splits an optional <, <=, >, >= or = off value and parses
+// the rest with parse
+func parseComparison(value string, parse func(string) (int64, error)) (func(a, b int64) bool, int64, error) {
+	var op func(a, b int64) bool
+	switch {
+	case strings.HasPrefix(value, ">="):
+		op, value = func(a, b int64) bool { return a >= b }, value[2:]
+	case strings.HasPrefix(value, "<="):
+		op, value = func(a, b int64) bool { return a <= b }, value[2:]
+	case strings.HasPrefix(value, ">"):
+		op, value = func(a, b int64) bool { return a > b }, value[1:]
+	case strings.HasPrefix(value, "<"):
+		op, value = func(a, b int64) bool { return a < b }, value[1:]
+	default:
+		op, value = func(a, b int64) bool { return a == b }, strings.TrimPrefix(value, "=")
+	}
+	if value == "" {
+		return nil, 0, fmt.Errorf("missing number")
+	}
+	n, err := parse(value)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid number %q", value)
+	}
+	return op, n, nil
+}
+
+// parseSize parses a size like 500, 500b, 1.5kb or 2mb into bytes
+func parseSize(s string) (int64, error) {
+	s = strings.ToLower(s)
+	units := []struct {
+		suffix string
+		scale  float64
+	}{
+		{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+		{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}, {"b", 1},
+	}
+	scale := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s, scale = strings.TrimSuffix(s, u.suffix), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size")
+	}
+	return int64(n * scale), nil
+}
@@ -0,0 +1,91 @@
+package crawler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestFilter_Match(t *testing.T) {
+	page := crawler.Resource{
+		URL: "https://example.com/docs/Guide.html", Status: "200", Kind: "document", Size: 2048, Depth: 1,
+		FromSource: "https://example.com/",
+		Headers:    http.Header{"Cache-Control": {"no-store, private"}},
+	}
+	pdf := crawler.Resource{URL: "https://example.com/files/report.pdf", Status: "404", Kind: "Other", Size: 600 * 1024, Depth: 2}
+	image := crawler.Resource{URL: "https://cdn.example.com/logo.png", Status: "Error", Kind: "png", External: true, Depth: 2}
+
+	links := crawler.NewLinkIndex()
+	links.AddLinks("https://example.com/", []string{page.URL})
+	links.AddLinks("https://example.com/about", []string{page.URL})
+
+	tests := []struct {
+		query string
+		want  []bool // page, pdf, image
+	}{
+		{"", []bool{true, true, true}},
+		{"guide", []bool{true, false, false}},
+		{"-type:image", []bool{true, true, false}},
+		{"type:png", []bool{false, false, true}},
+		{`type:"image"`, []bool{false, false, false}},
+		{"type:image OR status:404", []bool{false, true, true}},
+		{"type:image | type:document", []bool{true, false, true}},
+		{"status:4xx", []bool{false, true, false}},
+		{"status:>=400", []bool{false, true, false}},
+		{"status:200", []bool{true, false, false}},
+		{"status:40", []bool{false, true, false}},
+		{"status:2", []bool{true, false, false}},
+		{"status:err", []bool{false, false, true}},
+		{"size:>500kb", []bool{false, true, false}},
+		{"size:<=2kb", []bool{true, false, true}},
+		{`url:/\.pdf$/`, []bool{false, true, false}},
+		{`/\.(png|pdf)$/`, []bool{false, true, true}},
+		{`url:"/docs/"`, []bool{true, false, false}},
+		{"depth:2", []bool{false, true, true}},
+		{"depth:<2", []bool{true, false, false}},
+		{"header:cache-control", []bool{true, false, false}},
+		{"header:cache-control=no-store", []bool{true, false, false}},
+		{`header:Cache-Control="no-store, private"`, []bool{true, false, false}},
+		{"-header:cache-control=no-store", []bool{false, true, true}},
+		{"from:about", []bool{true, false, false}},
+		{"external:yes", []bool{false, false, true}},
+		{"(type:image OR type:other) -status:404", []bool{false, false, true}},
+		{"-(type:image OR type:other)", []bool{true, false, false}},
+		{"https://example.com", []bool{true, true, false}},
+	}
+
+	for _, tt := range tests {
+		f, err := crawler.ParseFilter(tt.query)
+		if err != nil {
+			t.Errorf("ParseFilter(%q) failed: %v", tt.query, err)
+			continue
+		}
+		for i, res := range []crawler.Resource{page, pdf, image} {
+			if got := f.Match(res, links); got != tt.want[i] {
+				t.Errorf("%q on %s: expected %v, got %v", tt.query, res.URL, tt.want[i], got)
+			}
+		}
+	}
+}
+
+func TestParseFilter_Errors(t *testing.T) {
+	for _, query := range []string{
+		"(type:image",
+		"type:image)",
+		"type:image OR",
+		"status:>=abc",
+		"status:4040",
+		"size:>lots",
+		"depth:>",
+		"external:maybe",
+		"header:",
+		"url:/[/",
+		`"unterminated`,
+		"(type:image -)",
+	} {
+		if _, err := crawler.ParseFilter(query); err == nil {
+			t.Errorf("Expected ParseFilter(%q) to fail", query)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package crawler
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// FrontierOrder decides which queued URL is crawled next
+type FrontierOrder string
+
+const (
+	// OrderBFS crawls URLs in the order they were found, level by level
+	OrderBFS FrontierOrder = "bfs"
+	// OrderDFS crawls the most recently found URL first, following each
+	// branch of the site as deep as it goes before backtracking
+	OrderDFS FrontierOrder = "dfs"
+	// OrderPriority crawls the URLs scored highest by the frontier's
+	// Prioritizers first, in the order they were found when scores tie
+	OrderPriority FrontierOrder = "priority"
+)
+
+// DefaultFrontierMemory is the number of queued URLs a Frontier keeps in
+// memory before spilling the rest to disk
+const DefaultFrontierMemory = 100_000
+
+// FrontierOptions configures a Frontier
+type FrontierOptions struct {
+	// Order picks the next URL to crawl. The default is OrderBFS.
+	Order FrontierOrder
+	// MemoryLimit is the number of queued URLs kept in memory. 0 means
+	// DefaultFrontierMemory.
+	MemoryLimit int
+	// SpillDir is where queued URLs past MemoryLimit are written. "" means
+	// the system temporary directory.
+	SpillDir string
+	// Prioritizers score URLs for OrderPriority. They are consulted in
+	// turn, so each only breaks ties left by the ones before it. The
+	// default is ShallowestFirst.
+	Prioritizers []Prioritizer
+}
+
+// Frontier is the queue of URLs waiting to be crawled. Adding to it never
+// blocks: past its memory limit, queued URLs are written to files on disk
+// and read back when their turn comes. A Frontier serves a single crawl and
+// its files are removed when the crawl ends.
+type Frontier struct {
+	mu       sync.Mutex
+	order    FrontierOrder
+	scorers  []Prioritizer
+	limit    int
+	spillDir string
+	dir      string // Created on the first spill
+	err      error
+
+	head   []frontierItem // BFS: oldest jobs. DFS: a stack. Priority: a heap.
+	tail   []frontierItem // BFS: jobs queued after the spills
+	spills []*spill       // BFS: oldest first. DFS: newest first at the end.
+	seq    uint64
+	size   int
+
+	ready   chan struct{}
+	dropped func(n int) // Called with the number of jobs lost to a read error
+}
+
+// frontierItem is a queued job, its position in the queue and, for
+// OrderPriority, its scores
+type frontierItem struct {
+	job
+	seq    uint64
+	scores []float64
+}
+
+// NewFrontier creates an empty Frontier
+func NewFrontier(opts FrontierOptions) (*Frontier, error) {
+	switch opts.Order {
+	case "":
+		opts.Order = OrderBFS
+	case OrderBFS, OrderDFS, OrderPriority:
+	default:
+		return nil, fmt.Errorf("unknown crawl order %q (want bfs, dfs or priority)", opts.Order)
+	}
+	if opts.MemoryLimit < 0 {
+		return nil, fmt.Errorf("frontier memory limit must not be negative")
+	}
+	if opts.MemoryLimit == 0 {
+		opts.MemoryLimit = DefaultFrontierMemory
+	}
+	if opts.Order == OrderPriority && len(opts.Prioritizers) == 0 {
+		opts.Prioritizers = []Prioritizer{ShallowestFirst()}
+	}
+	return &Frontier{
+		order:    opts.Order,
+		scorers:  opts.Prioritizers,
+		limit:    max(opts.MemoryLimit, 2),
+		spillDir: opts.SpillDir,
+		ready:    make(chan struct{}, 1),
+	}, nil
+}
+
+// Len returns the number of queued URLs
+func (f *Frontier) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.size
+}
+
+// Err returns the first error writing or reading the spill files. Jobs that
+// couldn't be written are kept in memory instead; jobs that couldn't be read
+// back are dropped.
+func (f *Frontier) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// push queues j
+func (f *Frontier) push(j job) {
+	var scores []float64
+	if f.order == OrderPriority {
+		u := QueuedURL{URL: j.url, Depth: j.depth, From: j.from}
+		scores = make([]float64, len(f.scorers))
+		for i, p := range f.scorers {
+			scores[i] = p.Priority(u)
+		}
+	}
+
+	f.mu.Lock()
+	f.seq++
+	it := frontierItem{job: j, seq: f.seq, scores: scores}
+	f.size++
+	chunk := f.limit / 2
+	switch f.order {
+	case OrderBFS:
+		if len(f.spills) == 0 && len(f.tail) == 0 && len(f.head) < f.limit {
+			f.head = append(f.head, it)
+		} else if f.tail = append(f.tail, it); len(f.tail) >= chunk && f.spill(f.tail) {
+			f.tail = nil
+		}
+	case OrderDFS:
+		f.head = append(f.head, it)
+		// The oldest jobs go to disk, written newest first so they read
+		// back in stack order
+		if len(f.head) > f.limit {
+			bottom := slices.Clone(f.head[:chunk])
+			slices.Reverse(bottom)
+			if f.spill(bottom) {
+				f.head = slices.Delete(f.head, 0, chunk)
+			}
+		}
+	case OrderPriority:
+		heap.Push((*itemHeap)(&f.head), it)
+		// The jobs that will come up last go to disk in the order they
+		// come up, to be merged back with the heap and each other
+		if len(f.head) > f.limit {
+			sorted := slices.Clone(f.head)
+			slices.SortFunc(sorted, comparePriority)
+			if f.spill(sorted[f.limit-chunk:]) {
+				f.head = sorted[:f.limit-chunk]
+			}
+		}
+	}
+	f.mu.Unlock()
+	f.signal()
+}
+
+// pop removes the next job, waiting for one to be queued if there are none.
+// It returns false if ctx is done first.
+func (f *Frontier) pop(ctx context.Context) (job, bool) {
+	for {
+		f.mu.Lock()
+		it, ok := f.next()
+		more := f.size > 0
+		f.mu.Unlock()
+		if more {
+			// Pass the wakeup on to the next waiting worker
+			f.signal()
+		}
+		if ok {
+			return it.job, true
+		}
+		select {
+		case <-f.ready:
+		case <-ctx.Done():
+			return job{}, false
+		}
+	}
+}
+
+func (f *Frontier) signal() {
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+}
+
+// next removes the next job. f.mu must be held.
+func (f *Frontier) next() (frontierItem, bool) {
+	var it frontierItem
+	var ok bool
+	switch f.order {
+	case OrderBFS:
+		if len(f.head) > 0 {
+			it, ok = f.head[0], true
+			f.head[0] = frontierItem{}
+			f.head = f.head[1:]
+		} else if len(f.spills) > 0 {
+			it, ok = f.read(0)
+		} else if len(f.tail) > 0 {
+			f.head, f.tail = f.tail, nil
+			return f.next()
+		}
+	case OrderDFS:
+		if n := len(f.head); n > 0 {
+			it, ok = f.head[n-1], true
+			f.head = f.head[:n-1]
+		} else if n := len(f.spills); n > 0 {
+			it, ok = f.read(n - 1)
+		}
+	case OrderPriority:
+		for _, s := range f.spills {
+			s.peek(f)
+		}
+		f.spills = slices.DeleteFunc(f.spills, func(s *spill) bool {
+			if s.n == 0 {
+				s.close()
+				return true
+			}
+			return false
+		})
+		best := -1
+		for i, s := range f.spills {
+			if best < 0 || comparePriority(s.next, f.spills[best].next) < 0 {
+				best = i
+			}
+		}
+		if len(f.head) > 0 && (best < 0 || comparePriority(f.head[0], f.spills[best].next) < 0) {
+			it, ok = heap.Pop((*itemHeap)(&f.head)).(frontierItem), true
+		} else if best >= 0 {
+			it, ok = f.read(best)
+		}
+	}
+	if ok {
+		f.size--
+	}
+	return it, ok
+}
+
+// comparePriority orders jobs for OrderPriority, highest scores first
+func comparePriority(a, b frontierItem) int {
+	for i := range a.scores {
+		if c := cmp.Compare(b.scores[i], a.scores[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(a.seq, b.seq)
+}
+
+// itemHeap is a heap of jobs in OrderPriority
+type itemHeap []frontierItem
+
+func (h itemHeap) Len() int           { return len(h) }
+func (h itemHeap) Less(i, j int) bool { return comparePriority(h[i], h[j]) < 0 }
+func (h itemHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *itemHeap) Push(x any)        { *h = append(*h, x.(frontierItem)) }
+func (h *itemHeap) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
+
+// close removes the spill files
+func (f *Frontier) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.spills {
+		s.close()
+	}
+	f.spills = nil
+	if f.dir != "" {
+		os.RemoveAll(f.dir)
+		f.dir = ""
+	}
+}
+
+// spill is a file of queued jobs, read back in the order they were written
+type spill struct {
+	path string
+	file *os.File
+	r    *bufio.Reader
+	n    int          // Jobs not yet read
+	next frontierItem // Read ahead by peek
+	held bool
+}
+
+// spillEntry is one line of a spill file
+type spillEntry struct {
+	journalEntry
+	Seq    uint64    `json:"seq"`
+	Scores []float64 `json:"scores,omitempty"`
+}
+
+// spill writes items to a new spill file, added to the end of f.spills. It
+// returns false if they should be kept in memory instead because the file
+// couldn't be written. f.mu must be held.
+func (f *Frontier) spill(items []frontierItem) bool {
+	if f.err != nil {
+		return false
+	}
+	err := func() error {
+		if f.dir == "" {
+			dir, err := os.MkdirTemp(f.spillDir, "huntsman-frontier-*")
+			if err != nil {
+				return err
+			}
+			f.dir = dir
+		}
+		file, err := os.CreateTemp(f.dir, "spill-*.jsonl")
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(file)
+		enc := json.NewEncoder(w)
+		for _, it := range items {
+			if err := enc.Encode(spillEntry{queueEntry(it.job), it.seq, it.scores}); err != nil {
+				file.Close()
+				os.Remove(file.Name())
+				return err
+			}
+		}
+		err = w.Flush()
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(file.Name())
+			return err
+		}
+		f.spills = append(f.spills, &spill{path: file.Name(), n: len(items)})
+		return nil
+	}()
+	if err != nil {
+		f.err = fmt.Errorf("spilling queued URLs to disk: %w", err)
+		return false
+	}
+	return true
+}
+
+// read removes the next job from f.spills[i], removing the spill once it
+// is used up. f.mu must be held.
+func (f *Frontier) read(i int) (frontierItem, bool) {
+	s := f.spills[i]
+	ok := s.peek(f)
+	it := s.next
+	s.held = false
+	if ok {
+		s.n--
+	}
+	if s.n == 0 {
+		s.close()
+		f.spills = slices.Delete(f.spills, i, i+1)
+	}
+	return it, ok
+}
+
+// peek reads the spill's next job into s.next. It returns false if there
+// is none, dropping the rest of the spill if it can't be read.
+func (s *spill) peek(f *Frontier) bool {
+	if s.held {
+		return true
+	}
+	if s.n == 0 {
+		return false
+	}
+	err := func() error {
+		if s.file == nil {
+			file, err := os.Open(s.path)
+			if err != nil {
+				return err
+			}
+			s.file, s.r = file, bufio.NewReader(file)
+		}
+		line, err := s.r.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		var e spillEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		s.next, s.held = frontierItem{job: e.job(), seq: e.Seq, scores: e.Scores}, true
+		return nil
+	}()
+	if err != nil {
+		if f.err == nil {
+			f.err = fmt.Errorf("reading queued URLs from disk: %w", err)
+		}
+		f.size -= s.n
+		if f.dropped != nil {
+			f.dropped(s.n)
+		}
+		s.n = 0
+		return false
+	}
+	return true
+}
+
+func (s *spill) close() {
+	if s.file != nil {
+		s.file.Close()
+	}
+	os.Remove(s.path)
+}
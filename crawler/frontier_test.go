@@ -0,0 +1,129 @@
+package crawler_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+// crawlOrder crawls site from its root with a single worker and returns
+// the resources in the order they were crawled
+func crawlOrder(t *testing.T, site map[string][]string, opts ...crawler.Option) []crawler.Resource {
+	t.Helper()
+	c := crawler.NewStandardCrawler(&MockCollectorWithLinks{Links: site}, crawler.NewInMemoryRegistry(), 1, opts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go c.Start(ctx, "http://example.com/")
+
+	var results []crawler.Resource
+	for res := range c.Results() {
+		results = append(results, res)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("Crawl stalled after %d results", len(results))
+	}
+	return results
+}
+
+func TestStandardCrawler_WideFanOut(t *testing.T) {
+	// More links on one page than the old job channel could hold, with a
+	// single worker that is also the only one taking jobs off the queue
+	const n = 25000
+	links := make([]string, n)
+	for i := range links {
+		links[i] = fmt.Sprintf("http://example.com/page/%d", i)
+	}
+	site := map[string][]string{"http://example.com/": links}
+
+	results := crawlOrder(t, site)
+	if len(results) != n+1 {
+		t.Errorf("Expected %d results, got %d", n+1, len(results))
+	}
+}
+
+// wideSite returns a site whose root links to 50 pages with 10 children each
+func wideSite() map[string][]string {
+	site := map[string][]string{}
+	for i := range 50 {
+		page := fmt.Sprintf("http://example.com/p%d", i)
+		site["http://example.com/"] = append(site["http://example.com/"], page)
+		for j := range 10 {
+			site[page] = append(site[page], fmt.Sprintf("%s/c%d", page, j))
+		}
+	}
+	return site
+}
+
+func TestFrontier_SpillsInOrder(t *testing.T) {
+	for _, order := range []crawler.FrontierOrder{crawler.OrderBFS, crawler.OrderDFS, crawler.OrderPriority} {
+		t.Run(string(order), func(t *testing.T) {
+			dir := t.TempDir()
+			frontier, err := crawler.NewFrontier(crawler.FrontierOptions{Order: order, MemoryLimit: 8, SpillDir: dir})
+			if err != nil {
+				t.Fatalf("NewFrontier failed: %v", err)
+			}
+			site := wideSite()
+			results := crawlOrder(t, site, crawler.WithFrontier(frontier))
+
+			seen := map[string]bool{}
+			for _, res := range results {
+				if seen[res.URL] {
+					t.Fatalf("%s was crawled twice", res.URL)
+				}
+				seen[res.URL] = true
+			}
+			if len(seen) != 551 {
+				t.Errorf("Expected 551 pages, got %d", len(seen))
+			}
+
+			if order == crawler.OrderDFS {
+				// Each page's children are crawled straight after it, the
+				// last linked first
+				for i, res := range results {
+					children := site[res.URL]
+					if res.Depth != 1 {
+						continue
+					}
+					got := make([]string, 0, len(children))
+					for _, child := range results[i+1 : i+1+len(children)] {
+						got = append(got, child.URL)
+					}
+					want := slices.Clone(children)
+					slices.Reverse(want)
+					if !slices.Equal(got, want) {
+						t.Fatalf("Expected %s to be followed by %v, got %v", res.URL, want, got)
+					}
+				}
+			} else {
+				for i := 1; i < len(results); i++ {
+					if results[i].Depth < results[i-1].Depth {
+						t.Fatalf("%s at depth %d was crawled after %s at depth %d",
+							results[i].URL, results[i].Depth, results[i-1].URL, results[i-1].Depth)
+					}
+				}
+				if results[1].URL != "http://example.com/p0" || results[51].URL != "http://example.com/p0/c0" {
+					t.Errorf("Expected pages in the order they were found, got %s and %s", results[1].URL, results[51].URL)
+				}
+			}
+
+			if frontier.Err() != nil {
+				t.Errorf("Unexpected frontier error: %v", frontier.Err())
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("Expected spill files to be removed, found %d", len(entries))
+			}
+		})
+	}
+}
+
+func TestNewFrontier_UnknownOrder(t *testing.T) {
+	if _, err := crawler.NewFrontier(crawler.FrontierOptions{Order: "random"}); err == nil {
+		t.Error("Expected an unknown order to be rejected")
+	}
+}
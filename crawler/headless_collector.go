@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,6 +13,9 @@ import (
 	"github.com/chromedp/chromedp"
 )
 
+// headClient is used for the HEAD check; it records redirects instead of following them
+var headClient = noFollowClient(http.DefaultClient)
+
 // HeadlessCollector uses a headless browser to collect resources
 type HeadlessCollector struct {
 }
@@ -25,35 +29,51 @@ func NewHeadlessCollector() *HeadlessCollector {
 func (c *HeadlessCollector) Collect(ctx context.Context, targetURL string) (*Resource, error) {
 	var size int64
 	var status string = "200" // Default
+	var robots []string
+	var finalURL string
+	var lastModified string
+	var headers http.Header
 
 	// Hybrid Check: Use HEAD request first
-	// We use a short timeout for the HEAD request to fail fast if it's not available
+	// We use a short timeout for the HEAD request to fail fast if it's not available.
+	// Redirects are followed by hand so the chain can be recorded.
 	headCtx, cancelHead := context.WithTimeout(ctx, 5*time.Second)
-	req, err := http.NewRequestWithContext(headCtx, "HEAD", targetURL, nil)
+	resp, hops, err := followRedirects(headCtx, headClient, "HEAD", targetURL)
+	cancelHead() // Cancel HEAD context immediately after response
 	if err == nil {
-		resp, err := http.DefaultClient.Do(req)
-		cancelHead() // Cancel HEAD context immediately after response
-		if err == nil {
-			defer resp.Body.Close()
-			size = resp.ContentLength
-			status = fmt.Sprintf("%d", resp.StatusCode)
-			
-			ctype := resp.Header.Get("Content-Type")
-			kind := DetermineKind(ctype)
-			// If it's a known non-document type, return immediately as static resource
-			if kind != "document" && kind != "Other" {
-				return &Resource{
-					URL:        targetURL,
-					Status:     status,
-					Kind:       kind,
-					Size:       size,
-					Links:      []string{},
-					FromSource: "",
-				}, nil
-			}
+		defer resp.Body.Close()
+		size = resp.ContentLength
+		status = fmt.Sprintf("%d", resp.StatusCode)
+		robots = robotsTagDirectives(resp.Header.Values("X-Robots-Tag"), userAgentFrom(ctx))
+		lastModified = resp.Header.Get("Last-Modified")
+		headers = resp.Header.Clone()
+		if len(hops) > 0 {
+			finalURL = resp.Request.URL.String()
+		}
+
+		ctype := resp.Header.Get("Content-Type")
+		kind := DetermineKind(ctype)
+		// If it's a known non-document type, or the chain never reached a page,
+		// return immediately as static resource
+		if (kind != "document" && kind != "Other") || isRedirectStatus(resp.StatusCode) {
+			return &Resource{
+				URL:        targetURL,
+				Status:     status,
+				Kind:       kind,
+				Size:       size,
+				Links:      []string{},
+				FromSource: "",
+				Robots:     strings.Join(robots, ", "),
+				Redirects:  hops,
+				FinalURL:   finalURL,
+
+				LastModified: lastModified,
+
+				Headers:         headers,
+				ContentType:     ctype,
+				ContentEncoding: contentEncoding(resp),
+			}, nil
 		}
-	} else {
-		cancelHead()
 	}
 
 	// Proceed with browser navigation
@@ -71,14 +91,27 @@ func (c *HeadlessCollector) Collect(ctx context.Context, targetURL string) (*Res
 		Kind:   "document", // Assume document if we are here
 		Status: status,     // Use status from HEAD check if available
 		Size:   size,       // Use size from HEAD check
+
+		Redirects: hops,
+		FinalURL:  finalURL,
+
+		LastModified: lastModified,
+
+		Headers:         headers,
+		ContentType:     headers.Get("Content-Type"),
+		ContentEncoding: headers.Get("Content-Encoding"),
+	}
+	pageURL := targetURL
+	if finalURL != "" {
+		pageURL = finalURL
 	}
 
 	// Selector for all resources we care about
-	selector := "a[href], link[href], img[src], script[src]"
+	selector := "a[href], link[href], img[src], script[src], meta[name='robots' i]"
 
 	// Run tasks
 	err = chromedp.Run(ctx,
-		chromedp.Navigate(targetURL),
+		chromedp.Navigate(pageURL),
 		chromedp.WaitVisible("body", chromedp.ByQuery),
 		// Wait a bit for JS to execute (simple heuristic)
 		chromedp.Sleep(2*time.Second),
@@ -91,7 +124,7 @@ func (c *HeadlessCollector) Collect(ctx context.Context, targetURL string) (*Res
 	}
 
 	var links []string
-	baseURL, _ := url.Parse(targetURL)
+	baseURL, _ := url.Parse(pageURL)
 
 	for _, n := range nodes {
 		var rawURL string
@@ -100,6 +133,9 @@ func (c *HeadlessCollector) Collect(ctx context.Context, targetURL string) (*Res
 			rawURL = n.AttributeValue("href")
 		} else if nodeName == "IMG" || nodeName == "SCRIPT" {
 			rawURL = n.AttributeValue("src")
+		} else if nodeName == "META" {
+			robots = append([]string{strings.ToLower(n.AttributeValue("content"))}, robots...)
+			continue
 		}
 
 		if rawURL != "" {
@@ -110,9 +146,13 @@ func (c *HeadlessCollector) Collect(ctx context.Context, targetURL string) (*Res
 			resolved := baseURL.ResolveReference(u)
 			resolved.Fragment = ""
 			links = append(links, resolved.String())
+			if nodeName == "LINK" && slices.Contains(strings.Fields(strings.ToLower(n.AttributeValue("rel"))), "canonical") {
+				res.Canonical = resolved.String()
+			}
 		}
 	}
 	res.Links = links
+	res.Robots = strings.Join(robots, ", ")
 
 	return res, nil
 }
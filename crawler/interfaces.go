@@ -2,16 +2,59 @@ package crawler
 
 import (
 	"context"
+	"net/http"
+	"strings"
 )
 
 // Resource represents a discovered resource (URL, script, image, etc.)
 type Resource struct {
-	URL        string
-	Status     string // Use string to support "Error" states
-	Kind       string // e.g., "document", "script", "image"
-	Size       int64
-	Links      []string // Outgoing links found on this resource
-	FromSource string   // The referrer URL where this resource was found
+	URL         string   `json:"url"`
+	OriginalURL string   `json:"original_url,omitempty"` // The URL as linked, when normalization changed it
+	Status      string   `json:"status"`                 // Use string to support "Error" states
+	Kind        string   `json:"kind"`                   // e.g., "document", "script", "image"
+	Size        int64    `json:"size"`
+	Links       []string `json:"links,omitempty"`       // Outgoing links found on this resource
+	FromSource  string   `json:"from_source,omitempty"` // The referrer URL where this resource was found
+	Depth       int      `json:"depth"`                 // Number of clicks from the start URL
+	Robots      string   `json:"robots,omitempty"`      // Directives from <meta name="robots"> and X-Robots-Tag that apply to the user agent
+	External    bool     `json:"external,omitempty"`    // Outside the crawl scope; checked but not crawled
+	Error       string   `json:"error,omitempty"`       // Error returned by the collector, if any
+
+	Redirects      []Redirect `json:"redirects,omitempty"`       // Each hop followed before the final response
+	FinalURL       string     `json:"final_url,omitempty"`       // Where the redirect chain ended
+	RedirectIssues []string   `json:"redirect_issues,omitempty"` // Loops, long chains and downgrades found in Redirects
+
+	Canonical    string `json:"canonical,omitempty"`     // Resolved href of <link rel="canonical">
+	LastModified string `json:"last_modified,omitempty"` // Last-Modified response header
+
+	Headers         http.Header `json:"headers,omitempty"` // Headers of the final response
+	ContentType     string      `json:"content_type,omitempty"`
+	ContentEncoding string      `json:"content_encoding,omitempty"`
+	Timing          *Timing     `json:"timing,omitempty"` // Only measured by StaticCollector
+}
+
+// KindRedirect is the Kind of a resource that redirected elsewhere
+const KindRedirect = "redirect"
+
+// NoIndex reports whether the page asked not to be indexed
+func (r Resource) NoIndex() bool {
+	return hasRobotsDirective(r.Robots, "noindex")
+}
+
+// NoFollow reports whether the page asked crawlers not to follow its links
+func (r Resource) NoFollow() bool {
+	return hasRobotsDirective(r.Robots, "nofollow")
+}
+
+// hasRobotsDirective checks a comma-separated directive list, treating
+// "none" as both noindex and nofollow
+func hasRobotsDirective(directives, want string) bool {
+	for _, d := range strings.Split(strings.ToLower(directives), ",") {
+		if d = strings.TrimSpace(d); d == want || d == "none" {
+			return true
+		}
+	}
+	return false
 }
 
 // Collector is responsible for fetching and parsing a single resource
@@ -23,7 +66,10 @@ type Collector interface {
 type Crawler interface {
 	Start(ctx context.Context, startURL string) error
 	Stop()
+	Pause()  // Hold the crawl once in-flight requests finish, keeping its queue
+	Resume() // Continue a paused crawl
 	Results() <-chan Resource
+	Events() <-chan Event // Crawl progress, including every result
 }
 
 // Registry manages the visited state of URLs to prevent redundant processing
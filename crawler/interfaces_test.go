@@ -35,6 +35,7 @@ func (m *MockRegistry) IsVisited(u string) bool {
 // MockCrawler ensures that the Crawler interface can be implemented
 type MockCrawler struct {
 	results chan crawler.Resource
+	events  chan crawler.Event
 }
 
 func (c *MockCrawler) Start(ctx context.Context, startURL string) error {
@@ -45,10 +46,18 @@ func (c *MockCrawler) Stop() {
 	close(c.results)
 }
 
+func (c *MockCrawler) Pause() {}
+
+func (c *MockCrawler) Resume() {}
+
 func (c *MockCrawler) Results() <-chan crawler.Resource {
 	return c.results
 }
 
+func (c *MockCrawler) Events() <-chan crawler.Event {
+	return c.events
+}
+
 func TestInterfaces(t *testing.T) {
 	// Verify that the mocks implement the interfaces
 	var _ crawler.Collector = &MockCollector{}
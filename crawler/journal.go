@@ -0,0 +1,257 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// journalFile is the name of the log kept in a journal directory
+const journalFile = "journal.jsonl"
+
+// Journal entry kinds
+const (
+	entryStart  = "start"
+	entryVisit  = "visit"
+	entryQueue  = "queue"
+	entryLinks  = "links"
+	entryResult = "result"
+	entryDone   = "done"
+)
+
+// journalEntry is one line of the journal. Everything a job produces is
+// tagged with the job's URL and only counts once that job is done, so a job
+// cut short by a crash or Stop is simply run again.
+type journalEntry struct {
+	Kind     string    `json:"kind"`
+	Job      string    `json:"job,omitempty"` // URL of the job that produced the entry
+	URL      string    `json:"url,omitempty"`
+	Original string    `json:"original,omitempty"`
+	Depth    int       `json:"depth,omitempty"`
+	From     string    `json:"from,omitempty"`
+	External bool      `json:"external,omitempty"`
+	Links    []string  `json:"links,omitempty"`
+	Resource *Resource `json:"resource,omitempty"`
+}
+
+func queueEntry(j job) journalEntry {
+	return journalEntry{Kind: entryQueue, URL: j.url, Original: j.original, Depth: j.depth, From: j.from, External: j.external}
+}
+
+// job returns the job described by a queue entry
+func (e journalEntry) job() job {
+	return job{url: e.URL, original: e.Original, depth: e.Depth, from: e.From, external: e.External}
+}
+
+// Journal records the progress of a crawl in a directory: visited URLs,
+// queued jobs, inbound links and reported resources. Opening the directory
+// again restores that state so WithJournal can continue the crawl without
+// fetching completed URLs again.
+type Journal struct {
+	mu   sync.Mutex
+	file *os.File
+	err  error
+
+	startURL  string
+	visited   []string
+	pending   []job
+	crawled   int64 // In-scope jobs done so far, for WithMaxPages
+	links     []journalEntry
+	resources []Resource
+}
+
+// OpenJournal opens the journal in dir, creating the directory if needed.
+// An existing journal is loaded and compacted to the entries that count.
+func OpenJournal(dir string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, journalFile)
+
+	var applied []journalEntry
+	j := &Journal{}
+	if f, err := os.Open(path); err == nil {
+		applied, err = j.load(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading journal %s: %w", path, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// Rewrite the log without entries from unfinished jobs, which would
+	// otherwise be duplicated when those jobs run again
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range applied {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		f.Close()
+		return nil, err
+	}
+	j.file = f
+	return j, nil
+}
+
+// load replays the log, applying each job's entries once it is done and the
+// job that queued it has been applied. It returns the applied entries.
+func (j *Journal) load(r io.Reader) ([]journalEntry, error) {
+	var applied []journalEntry
+	buffered := make(map[string][]journalEntry)
+	queued := make(map[string]bool)
+	external := make(map[string]bool)
+	done := make(map[string]bool)
+	finished := make(map[string]bool)
+	var pending []job
+
+	var apply func(e journalEntry)
+	finish := func(u string) {
+		entries := buffered[u]
+		delete(buffered, u)
+		finished[u] = true
+		if !external[u] {
+			j.crawled++
+		}
+		for _, e := range entries {
+			apply(e)
+		}
+		applied = append(applied, journalEntry{Kind: entryDone, URL: u})
+	}
+	apply = func(e journalEntry) {
+		applied = append(applied, e)
+		switch e.Kind {
+		case entryStart:
+			j.startURL = e.URL
+		case entryVisit:
+			j.visited = append(j.visited, e.URL)
+		case entryQueue:
+			queued[e.URL] = true
+			external[e.URL] = e.External
+			pending = append(pending, e.job())
+			// A job can finish before the job that queued it
+			if done[e.URL] {
+				finish(e.URL)
+			}
+		case entryLinks:
+			j.links = append(j.links, e)
+		case entryResult:
+			j.resources = append(j.resources, *e.Resource)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	var torn bool
+	for scanner.Scan() {
+		if torn {
+			return nil, fmt.Errorf("corrupt entry")
+		}
+		var e journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || (e.Kind == entryResult && e.Resource == nil) {
+			// The last line may have been cut short by a crash
+			torn = true
+			continue
+		}
+		switch {
+		case e.Kind == entryDone:
+			done[e.URL] = true
+			if queued[e.URL] && !finished[e.URL] {
+				finish(e.URL)
+			}
+		case e.Job == "":
+			apply(e)
+		default:
+			buffered[e.Job] = append(buffered[e.Job], e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range pending {
+		if !finished[p.url] {
+			j.pending = append(j.pending, p)
+		}
+	}
+	return applied, nil
+}
+
+// StartURL returns the start URL of the journaled crawl, or "" for a new journal
+func (j *Journal) StartURL() string {
+	return j.startURL
+}
+
+// Resumed reports whether the journal holds a crawl to continue
+func (j *Journal) Resumed() bool {
+	return j.startURL != ""
+}
+
+// Resources returns the resources reported before the journal was opened
+func (j *Journal) Resources() []Resource {
+	return j.resources
+}
+
+// Err returns the first error writing to the journal, if any
+func (j *Journal) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// write appends e to the log on behalf of the job with URL jobURL, or ""
+// for entries that don't belong to a job. Write errors are kept for Err
+// rather than stopping the crawl.
+func (j *Journal) write(jobURL string, e journalEntry) {
+	e.Job = jobURL
+	line, err := json.Marshal(e)
+	if err != nil {
+		j.fail(err)
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err != nil {
+		return
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		j.err = err
+	}
+}
+
+func (j *Journal) fail(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err == nil {
+		j.err = err
+	}
+}
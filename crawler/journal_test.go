@@ -0,0 +1,179 @@
+package crawler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+// InterruptingCollector serves Links and calls Interrupt instead of
+// fetching InterruptAt, as if the process had been killed there
+type InterruptingCollector struct {
+	Links       map[string][]string
+	InterruptAt string
+	Interrupt   func()
+
+	mu   sync.Mutex
+	URLs []string
+}
+
+func (c *InterruptingCollector) Collect(ctx context.Context, targetURL string) (*crawler.Resource, error) {
+	if targetURL == c.InterruptAt && c.Interrupt != nil {
+		c.Interrupt()
+		<-ctx.Done()
+		return &crawler.Resource{URL: targetURL, Status: "Error"}, ctx.Err()
+	}
+	c.mu.Lock()
+	c.URLs = append(c.URLs, targetURL)
+	c.mu.Unlock()
+	return &crawler.Resource{URL: targetURL, Status: "200", Links: c.Links[targetURL]}, nil
+}
+
+func runJournaled(t *testing.T, dir string, collector crawler.Collector, cancelled func(context.CancelFunc)) []string {
+	t.Helper()
+	journal, err := crawler.OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	defer journal.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cancelled(cancel)
+
+	links := crawler.NewLinkIndex()
+	c := crawler.NewStandardCrawler(collector, crawler.NewInMemoryRegistry(), 1,
+		crawler.WithJournal(journal), crawler.WithLinkIndex(links))
+	results := c.Results()
+	go c.Start(ctx, "http://example.com/")
+
+	// The page being fetched when the crawl is cut short may be reported
+	// with an error; it is fetched again on resume
+	var urls []string
+	for res := range results {
+		if res.Error == "" {
+			urls = append(urls, res.URL)
+		}
+	}
+	if err := journal.Err(); err != nil {
+		t.Fatalf("Journal write failed: %v", err)
+	}
+	slices.Sort(urls)
+	return urls
+}
+
+func TestStandardCrawler_ResumesFromJournal(t *testing.T) {
+	site := map[string][]string{
+		"http://example.com/":  {"http://example.com/a"},
+		"http://example.com/a": {"http://example.com/b", "http://example.com/c"},
+		"http://example.com/b": {"http://example.com/"},
+		"http://example.com/c": {},
+	}
+	dir := t.TempDir()
+
+	first := &InterruptingCollector{Links: site, InterruptAt: "http://example.com/b"}
+	urls := runJournaled(t, dir, first, func(cancel context.CancelFunc) { first.Interrupt = cancel })
+	if !slices.Equal(urls, []string{"http://example.com/", "http://example.com/a"}) {
+		t.Fatalf("Expected the first run to stop after /a, got %v", urls)
+	}
+
+	second := &InterruptingCollector{Links: site}
+	urls = runJournaled(t, dir, second, func(context.CancelFunc) {})
+	want := []string{"http://example.com/", "http://example.com/a", "http://example.com/b", "http://example.com/c"}
+	if !slices.Equal(urls, want) {
+		t.Errorf("Expected every page to be reported once, got %v", urls)
+	}
+	slices.Sort(second.URLs)
+	if !slices.Equal(second.URLs, []string{"http://example.com/b", "http://example.com/c"}) {
+		t.Errorf("Expected only the unfinished pages to be fetched again, got %v", second.URLs)
+	}
+
+	// A finished crawl is replayed without fetching anything
+	third := &InterruptingCollector{Links: site}
+	if urls := runJournaled(t, dir, third, func(context.CancelFunc) {}); !slices.Equal(urls, want) {
+		t.Errorf("Expected the finished crawl to be replayed, got %v", urls)
+	}
+	if len(third.URLs) != 0 {
+		t.Errorf("Expected nothing to be fetched, got %v", third.URLs)
+	}
+}
+
+func TestStandardCrawler_ResumeReplaysEveryResource(t *testing.T) {
+	var pages []string
+	for i := range 300 {
+		pages = append(pages, fmt.Sprintf("http://example.com/%d", i))
+	}
+	site := map[string][]string{"http://example.com/": pages}
+	dir := t.TempDir()
+	if urls := runJournaled(t, dir, &InterruptingCollector{Links: site}, func(context.CancelFunc) {}); len(urls) != 301 {
+		t.Fatalf("Expected the first run to crawl 301 pages, got %d", len(urls))
+	}
+
+	journal, err := crawler.OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	defer journal.Close()
+	c := crawler.NewStandardCrawler(&InterruptingCollector{Links: site}, crawler.NewInMemoryRegistry(), 1,
+		crawler.WithJournal(journal))
+
+	// Ask for the stream up front, as the CLI and TUI do, but only start
+	// reading once the replay is under way
+	events := c.Events()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go c.Start(ctx, "http://example.com/")
+	time.Sleep(20 * time.Millisecond)
+
+	replayed := 0
+	for e := range events {
+		if e.Resource != nil {
+			replayed++
+		}
+	}
+	if replayed != 301 {
+		t.Errorf("Expected all 301 journaled resources to be replayed, got %d", replayed)
+	}
+}
+
+func TestOpenJournal_TornLastLine(t *testing.T) {
+	dir := t.TempDir()
+	journal, err := crawler.OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	journal.Close()
+
+	f, err := os.OpenFile(filepath.Join(dir, "journal.jsonl"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"kind":"start","url":"http://example.com/"}` + "\n" + `{"kind":"queue","url":"http://exa`)
+	f.Close()
+
+	journal, err = crawler.OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("Expected a torn last line to be ignored, got %v", err)
+	}
+	defer journal.Close()
+	if journal.StartURL() != "http://example.com/" {
+		t.Errorf("Expected the start URL to be restored, got %q", journal.StartURL())
+	}
+
+	c := crawler.NewStandardCrawler(&MockCollector{}, crawler.NewInMemoryRegistry(), 1, crawler.WithJournal(journal))
+	go func() {
+		for range c.Results() {
+		}
+	}()
+	if err := c.Start(context.Background(), "http://other.com/"); err == nil || errors.Is(err, context.Canceled) {
+		t.Errorf("Expected resuming a different start URL to fail, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// LinkChecker implements the Collector interface for links that should be
+// checked but not crawled. It only reports the status, kind and size of the
+// target and never returns outgoing links.
+type LinkChecker struct {
+	client *http.Client
+}
+
+// NewLinkChecker creates a new LinkChecker with a default HTTP client
+func NewLinkChecker() *LinkChecker {
+	return &LinkChecker{
+		client: noFollowClient(&http.Client{
+			Timeout: 10 * time.Second,
+		}),
+	}
+}
+
+// Collect checks targetURL with a HEAD request, falling back to GET for
+// servers that reject or mishandle HEAD
+func (c *LinkChecker) Collect(ctx context.Context, targetURL string) (*Resource, error) {
+	resp, hops, err := c.do(ctx, "HEAD", targetURL)
+	if err != nil || resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode >= 400 {
+		// Many servers answer HEAD with 403, 404 or 405 while GET works fine
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, hops, err = c.do(ctx, "GET", targetURL)
+	}
+	if err != nil {
+		return &Resource{URL: targetURL, Status: "Error", Kind: "N/A", Redirects: hops}, err
+	}
+	defer resp.Body.Close()
+
+	size := resp.ContentLength
+	if size < 0 {
+		size = 0
+	}
+
+	res := &Resource{
+		URL:       targetURL,
+		Status:    fmt.Sprintf("%d", resp.StatusCode),
+		Kind:      DetermineKind(resp.Header.Get("Content-Type")),
+		Size:      size,
+		Redirects: hops,
+
+		Headers:         resp.Header.Clone(),
+		ContentType:     resp.Header.Get("Content-Type"),
+		ContentEncoding: contentEncoding(resp),
+	}
+	if len(hops) > 0 {
+		res.FinalURL = resp.Request.URL.String()
+	}
+	return res, nil
+}
+
+func (c *LinkChecker) do(ctx context.Context, method, targetURL string) (*http.Response, []Redirect, error) {
+	resp, hops, err := followRedirects(ctx, c.client, method, targetURL)
+	if err != nil {
+		return nil, hops, err
+	}
+	if method == "GET" {
+		// Only the status matters; don't download large bodies
+		io.CopyN(io.Discard, resp.Body, 64*1024)
+	}
+	return resp, hops, nil
+}
@@ -0,0 +1,50 @@
+package crawler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestLinkChecker_Collect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "HEAD" {
+			t.Errorf("Expected HEAD request, got %s", r.Method)
+		}
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer ts.Close()
+
+	res, err := crawler.NewLinkChecker().Collect(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if res.Status != "200" || res.Kind != "document" {
+		t.Errorf("Expected 200 document, got %s %s", res.Status, res.Kind)
+	}
+	if len(res.Links) != 0 {
+		t.Errorf("Expected no links, got %v", res.Links)
+	}
+}
+
+func TestLinkChecker_FallsBackToGet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	res, err := crawler.NewLinkChecker().Collect(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if res.Status != "200" {
+		t.Errorf("Expected GET fallback to return 200, got %s", res.Status)
+	}
+}
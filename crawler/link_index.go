@@ -0,0 +1,49 @@
+package crawler
+
+import "sync"
+
+// LinkIndex records every page that links to a given URL. A nil LinkIndex
+// records nothing and knows no referrers.
+type LinkIndex struct {
+	mu      sync.RWMutex
+	inbound map[string][]string
+}
+
+// NewLinkIndex creates an empty LinkIndex
+func NewLinkIndex() *LinkIndex {
+	return &LinkIndex{
+		inbound: make(map[string][]string),
+	}
+}
+
+// AddLinks records that from links to each of the given URLs.
+// Duplicate links within the same call are only recorded once.
+func (i *LinkIndex) AddLinks(from string, links []string) {
+	if i == nil {
+		return
+	}
+	seen := make(map[string]bool, len(links))
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, to := range links {
+		if seen[to] {
+			continue
+		}
+		seen[to] = true
+		i.inbound[to] = append(i.inbound[to], from)
+	}
+}
+
+// Referrers returns every page known to link to u, in discovery order
+func (i *LinkIndex) Referrers(u string) []string {
+	if i == nil {
+		return nil
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	refs := i.inbound[u]
+	out := make([]string, len(refs))
+	copy(out, refs)
+	return out
+}
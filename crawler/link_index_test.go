@@ -0,0 +1,26 @@
+package crawler_test
+
+import (
+	"testing"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestLinkIndex_AddLinks(t *testing.T) {
+	idx := crawler.NewLinkIndex()
+
+	idx.AddLinks("http://example.com/", []string{"http://example.com/a", "http://example.com/a"})
+	idx.AddLinks("http://example.com/b", []string{"http://example.com/a"})
+
+	refs := idx.Referrers("http://example.com/a")
+	if len(refs) != 2 {
+		t.Fatalf("Expected 2 referrers, got %v", refs)
+	}
+	if refs[0] != "http://example.com/" || refs[1] != "http://example.com/b" {
+		t.Errorf("Unexpected referrer order: %v", refs)
+	}
+
+	if len(idx.Referrers("http://example.com/missing")) != 0 {
+		t.Error("Expected no referrers for unknown URL")
+	}
+}
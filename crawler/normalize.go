@@ -0,0 +1,169 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// TrailingSlash is a policy for trailing slashes on URL paths
+type TrailingSlash string
+
+const (
+	// TrailingSlashKeep leaves paths as they are
+	TrailingSlashKeep TrailingSlash = "keep"
+	// TrailingSlashAdd adds a slash to paths whose last segment has no file extension
+	TrailingSlashAdd TrailingSlash = "add"
+	// TrailingSlashRemove strips the slash from every path except the root
+	TrailingSlashRemove TrailingSlash = "remove"
+)
+
+// DefaultTrackingParams are query parameters stripped by default. They only
+// tag where a visitor came from and never change the page.
+var DefaultTrackingParams = []string{
+	"utm_*", "gclid", "dclid", "fbclid", "msclkid", "yclid", "mc_cid", "mc_eid", "_ga", "_gl",
+}
+
+// NormalizeOptions configures a Normalizer
+type NormalizeOptions struct {
+	StripParams   []string      // Query parameter names to remove, as path.Match patterns like utm_*
+	TrailingSlash TrailingSlash // Defaults to TrailingSlashKeep
+	LowercasePath bool          // Treat paths as case-insensitive
+}
+
+// Normalizer rewrites URLs into a canonical form so that different spellings
+// of the same URL are only crawled once. It lowercases the scheme and host,
+// strips default ports, fragments and dot-segments, sorts query parameters
+// and removes tracking parameters.
+type Normalizer struct {
+	stripParams   []string
+	trailingSlash TrailingSlash
+	lowercasePath bool
+}
+
+// NewNormalizer creates a Normalizer, checking that the strip patterns are valid
+func NewNormalizer(opts NormalizeOptions) (*Normalizer, error) {
+	var strip []string
+	for _, p := range opts.StripParams {
+		p = strings.ToLower(p)
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid parameter pattern %q: %w", p, err)
+		}
+		strip = append(strip, p)
+	}
+	switch opts.TrailingSlash {
+	case "":
+		opts.TrailingSlash = TrailingSlashKeep
+	case TrailingSlashKeep, TrailingSlashAdd, TrailingSlashRemove:
+	default:
+		return nil, fmt.Errorf("unknown trailing slash policy %q (want keep, add or remove)", opts.TrailingSlash)
+	}
+	return &Normalizer{
+		stripParams:   strip,
+		trailingSlash: opts.TrailingSlash,
+		lowercasePath: opts.LowercasePath,
+	}, nil
+}
+
+// Normalize returns the canonical form of raw. URLs that can't be parsed, or
+// aren't http or https, are returned unchanged.
+func (n *Normalizer) Normalize(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return raw
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	// Work on the escaped path so that escapes like %2F keep their meaning
+	escaped := n.normalizePath(u.EscapedPath())
+	unescaped, err := url.PathUnescape(escaped)
+	if err != nil {
+		return raw
+	}
+	u.Path, u.RawPath = unescaped, escaped
+
+	if u.RawQuery != "" {
+		u.RawQuery = n.normalizeQuery(u.RawQuery)
+	}
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+// normalizePath removes dot-segments and applies the case and trailing slash policies
+func (n *Normalizer) normalizePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	trailing := strings.HasSuffix(p, "/") || strings.HasSuffix(p, "/.") || strings.HasSuffix(p, "/..")
+	p = path.Clean("/" + p)
+	if trailing && p != "/" {
+		p += "/"
+	}
+	if n.lowercasePath {
+		p = strings.ToLower(p)
+	}
+
+	switch n.trailingSlash {
+	case TrailingSlashAdd:
+		if !strings.HasSuffix(p, "/") && !strings.Contains(path.Base(p), ".") {
+			p += "/"
+		}
+	case TrailingSlashRemove:
+		if p != "/" {
+			p = strings.TrimSuffix(p, "/")
+		}
+	}
+	return p
+}
+
+// normalizeQuery removes stripped parameters and sorts the rest by name,
+// keeping each parameter exactly as written, including ones without a value
+func (n *Normalizer) normalizeQuery(rawQuery string) string {
+	type param struct{ name, raw string }
+	var params []param
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(raw, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if n.stripped(name) {
+			continue
+		}
+		params = append(params, param{name, raw})
+	}
+	slices.SortStableFunc(params, func(a, b param) int {
+		return strings.Compare(a.name, b.name)
+	})
+	raws := make([]string, len(params))
+	for i, p := range params {
+		raws[i] = p.raw
+	}
+	return strings.Join(raws, "&")
+}
+
+// stripped reports whether the query parameter name matches a strip pattern
+func (n *Normalizer) stripped(name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range n.stripParams {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package crawler_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	n, err := crawler.NewNormalizer(crawler.NormalizeOptions{StripParams: crawler.DefaultTrackingParams})
+	if err != nil {
+		t.Fatalf("NewNormalizer failed: %v", err)
+	}
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"HTTP://Example.COM:80/about", "http://example.com/about"},
+		{"https://example.com:443/about", "https://example.com/about"},
+		{"https://example.com:8443/about", "https://example.com:8443/about"},
+		{"https://example.com", "https://example.com/"},
+		{"https://example.com/a/./b/../c", "https://example.com/a/c"},
+		{"https://example.com/a/b/..", "https://example.com/a/"},
+		{"https://example.com/about#team", "https://example.com/about"},
+		{"https://example.com/?b=2&a=1", "https://example.com/?a=1&b=2"},
+		{"https://example.com/about?utm_source=x&UTM_Medium=y&id=3&fbclid=z", "https://example.com/about?id=3"},
+		{"https://example.com/about?utm_source=x", "https://example.com/about"},
+		{"mailto:Someone@Example.com", "mailto:Someone@Example.com"},
+		{"https://example.com/files/a%2Fb", "https://example.com/files/a%2Fb"},
+		{"https://example.com/caf%C3%A9", "https://example.com/caf%C3%A9"},
+		{"https://example.com/list?preview", "https://example.com/list?preview"},
+		{"https://example.com/list?sort=asc&flag&a=1&a=0", "https://example.com/list?a=1&a=0&flag&sort=asc"},
+		{"https://example.com/list?q=a+b&utm_source=x", "https://example.com/list?q=a+b"},
+	}
+	for _, tt := range tests {
+		if got := n.Normalize(tt.raw); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizer_Policies(t *testing.T) {
+	tests := []struct {
+		opts crawler.NormalizeOptions
+		raw  string
+		want string
+	}{
+		{crawler.NormalizeOptions{}, "https://example.com/about/", "https://example.com/about/"},
+		{crawler.NormalizeOptions{TrailingSlash: crawler.TrailingSlashAdd}, "https://example.com/about", "https://example.com/about/"},
+		{crawler.NormalizeOptions{TrailingSlash: crawler.TrailingSlashAdd}, "https://example.com/logo.png", "https://example.com/logo.png"},
+		{crawler.NormalizeOptions{TrailingSlash: crawler.TrailingSlashRemove}, "https://example.com/about/", "https://example.com/about"},
+		{crawler.NormalizeOptions{TrailingSlash: crawler.TrailingSlashRemove}, "https://example.com/", "https://example.com/"},
+		{crawler.NormalizeOptions{LowercasePath: true}, "https://example.com/About", "https://example.com/about"},
+	}
+	for _, tt := range tests {
+		n, err := crawler.NewNormalizer(tt.opts)
+		if err != nil {
+			t.Fatalf("NewNormalizer failed: %v", err)
+		}
+		if got := n.Normalize(tt.raw); got != tt.want {
+			t.Errorf("Normalize(%q) with %+v = %q, want %q", tt.raw, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestNewNormalizer_Invalid(t *testing.T) {
+	if _, err := crawler.NewNormalizer(crawler.NormalizeOptions{StripParams: []string{"utm_["}}); err == nil {
+		t.Error("Expected an error for a malformed pattern")
+	}
+	if _, err := crawler.NewNormalizer(crawler.NormalizeOptions{TrailingSlash: "sometimes"}); err == nil {
+		t.Error("Expected an error for an unknown trailing slash policy")
+	}
+}
+
+func TestStandardCrawler_Normalizer(t *testing.T) {
+	collector := &MockCollectorWithLinks{Links: map[string][]string{
+		"http://example.com/": {
+			"http://example.com/about",
+			"http://example.com/about/",
+			"http://example.com/About",
+			"http://example.com/about?utm_source=x",
+			"HTTP://EXAMPLE.com:80/about",
+		},
+	}}
+	n, err := crawler.NewNormalizer(crawler.NormalizeOptions{
+		StripParams:   crawler.DefaultTrackingParams,
+		TrailingSlash: crawler.TrailingSlashRemove,
+		LowercasePath: true,
+	})
+	if err != nil {
+		t.Fatalf("NewNormalizer failed: %v", err)
+	}
+	c := crawler.NewStandardCrawler(collector, crawler.NewInMemoryRegistry(), 2, crawler.WithNormalizer(n))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go c.Start(ctx, "HTTP://Example.com")
+
+	var results []crawler.Resource
+	for res := range c.Results() {
+		results = append(results, res)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected the start URL and one /about, got %v", results)
+	}
+	if results[0].URL != "http://example.com/" || results[0].OriginalURL != "HTTP://Example.com" {
+		t.Errorf("Expected normalized start URL with original kept, got %q from %q", results[0].URL, results[0].OriginalURL)
+	}
+	if results[1].URL != "http://example.com/about" || results[1].OriginalURL != "" {
+		t.Errorf("Expected /about as first linked, got %q from %q", results[1].URL, results[1].OriginalURL)
+	}
+	for _, link := range results[0].Links {
+		if link != "http://example.com/about" {
+			t.Errorf("Expected reported links to be normalized, got %q", link)
+		}
+	}
+	if refs := c.Links().Referrers("http://example.com/about"); len(refs) != 1 {
+		t.Errorf("Expected one referrer for the normalized URL, got %v", refs)
+	}
+}
+
+// CanonicalCollector serves a page whose canonical link is Canonical
+type CanonicalCollector struct {
+	Canonical string
+}
+
+func (c *CanonicalCollector) Collect(ctx context.Context, targetURL string) (*crawler.Resource, error) {
+	return &crawler.Resource{URL: targetURL, Status: "200", Kind: "document", Canonical: c.Canonical}, nil
+}
+
+func TestStandardCrawler_NormalizesCanonical(t *testing.T) {
+	n, err := crawler.NewNormalizer(crawler.NormalizeOptions{
+		TrailingSlash: crawler.TrailingSlashRemove,
+		LowercasePath: true,
+	})
+	if err != nil {
+		t.Fatalf("NewNormalizer failed: %v", err)
+	}
+	collector := &CanonicalCollector{Canonical: "http://example.com/Docs/?b=2&a=1"}
+	c := crawler.NewStandardCrawler(collector, crawler.NewInMemoryRegistry(), 1, crawler.WithNormalizer(n))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go c.Start(ctx, "http://example.com/docs?a=1&b=2")
+
+	var results []crawler.Resource
+	for res := range c.Results() {
+		results = append(results, res)
+	}
+	if len(results) != 1 || results[0].Canonical != results[0].URL {
+		t.Fatalf("Expected a self-canonical page, got %+v", results)
+	}
+	if entries := crawler.SitemapEntries(results); len(entries) != 1 {
+		t.Errorf("Expected the page in the sitemap, got %v", entries)
+	}
+}
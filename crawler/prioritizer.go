@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// QueuedURL describes a URL waiting to be crawled
+type QueuedURL struct {
+	URL   string
+	Depth int
+	From  string // The page that linked to it, or SourceSitemap
+}
+
+// Prioritizer scores URLs for OrderPriority. URLs with higher scores are
+// crawled first.
+type Prioritizer interface {
+	Priority(u QueuedURL) float64
+}
+
+// PrioritizerFunc adapts a function to a Prioritizer
+type PrioritizerFunc func(u QueuedURL) float64
+
+func (f PrioritizerFunc) Priority(u QueuedURL) float64 {
+	return f(u)
+}
+
+// ShallowestFirst prefers URLs fewer clicks from the start URL
+func ShallowestFirst() Prioritizer {
+	return PrioritizerFunc(func(u QueuedURL) float64 {
+		return -float64(u.Depth)
+	})
+}
+
+// assetExtensions are file extensions of URLs that aren't HTML documents
+var assetExtensions = map[string]bool{
+	".css": true, ".js": true, ".mjs": true, ".map": true, ".json": true, ".xml": true, ".txt": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".avif": true, ".svg": true, ".ico": true, ".bmp": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp4": true, ".webm": true, ".mov": true, ".mp3": true, ".wav": true, ".ogg": true,
+	".pdf": true, ".zip": true, ".gz": true, ".tar": true, ".dmg": true, ".exe": true,
+	".doc": true, ".docx": true, ".xls": true, ".xlsx": true, ".ppt": true, ".pptx": true,
+}
+
+// PagesFirst prefers URLs that look like HTML documents over images,
+// scripts, stylesheets and other assets, judging by their file extension
+func PagesFirst() Prioritizer {
+	return PrioritizerFunc(func(u QueuedURL) float64 {
+		parsed, err := url.Parse(u.URL)
+		if err != nil || assetExtensions[strings.ToLower(path.Ext(parsed.Path))] {
+			return 0
+		}
+		return 1
+	})
+}
+
+// SitemapPriority prefers URLs by their <priority> in sm's sitemaps, from
+// 1.0 down to 0.0 (0.5 when a URL doesn't declare one). URLs missing from
+// the sitemaps, including those queued before they were loaded, come last.
+func SitemapPriority(sm *Sitemap) Prioritizer {
+	return PrioritizerFunc(func(u QueuedURL) float64 {
+		if priority, ok := sm.priority(u.URL); ok {
+			return priority
+		}
+		return -1
+	})
+}
+
+// PatternBoost raises the score of URLs matching Pattern, a glob or
+// /regex/ as accepted by CompilePattern, by Boost. A negative Boost lowers it.
+type PatternBoost struct {
+	Pattern string
+	Boost   float64
+}
+
+// patternBoosts is a Prioritizer that adds up the boosts of every matching pattern
+type patternBoosts []compiledBoost
+
+type compiledBoost struct {
+	re    *regexp.Regexp
+	boost float64
+}
+
+// NewPatternBoosts returns a Prioritizer scoring each URL with the total
+// boost of the patterns it matches, or 0 if it matches none
+func NewPatternBoosts(boosts ...PatternBoost) (Prioritizer, error) {
+	p := make(patternBoosts, 0, len(boosts))
+	for _, b := range boosts {
+		re, err := CompilePattern(b.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, compiledBoost{re: re, boost: b.Boost})
+	}
+	return p, nil
+}
+
+func (p patternBoosts) Priority(u QueuedURL) float64 {
+	var score float64
+	for _, b := range p {
+		if b.re.MatchString(u.URL) {
+			score += b.boost
+		}
+	}
+	return score
+}
@@ -0,0 +1,93 @@
+package crawler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestStandardCrawler_PriorityWithBudget(t *testing.T) {
+	site := map[string][]string{
+		"http://example.com/": {
+			"http://example.com/style.css",
+			"http://example.com/logo.png",
+			"http://example.com/a",
+			"http://example.com/b",
+			"http://example.com/c",
+		},
+		"http://example.com/a": {"http://example.com/pricing/plans"},
+	}
+	boosts, err := crawler.NewPatternBoosts(crawler.PatternBoost{Pattern: "**/pricing/**", Boost: 10})
+	if err != nil {
+		t.Fatalf("NewPatternBoosts failed: %v", err)
+	}
+	frontier, err := crawler.NewFrontier(crawler.FrontierOptions{
+		Order:        crawler.OrderPriority,
+		Prioritizers: []crawler.Prioritizer{boosts, crawler.PagesFirst(), crawler.ShallowestFirst()},
+	})
+	if err != nil {
+		t.Fatalf("NewFrontier failed: %v", err)
+	}
+
+	results := crawlOrder(t, site, crawler.WithFrontier(frontier), crawler.WithMaxPages(4))
+	var urls []string
+	for _, res := range results {
+		urls = append(urls, res.URL)
+	}
+	// The boosted page comes up as soon as it is found, ahead of pages
+	// found earlier, and assets are left for last
+	want := []string{"http://example.com/", "http://example.com/a", "http://example.com/pricing/plans", "http://example.com/b"}
+	if !slices.Equal(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+}
+
+func TestSitemapPriority(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<urlset>
+<url><loc>` + ts.URL + `/low</loc><priority>0.1</priority></url>
+<url><loc>` + ts.URL + `/default</loc></url>
+<url><loc>` + ts.URL + `/high</loc><priority>0.9</priority></url>
+</urlset>`))
+	}))
+	defer ts.Close()
+
+	sm := crawler.NewSitemap("huntsman", ts.URL+"/sitemap.xml")
+	frontier, err := crawler.NewFrontier(crawler.FrontierOptions{
+		Order:        crawler.OrderPriority,
+		Prioritizers: []crawler.Prioritizer{crawler.SitemapPriority(sm)},
+	})
+	if err != nil {
+		t.Fatalf("NewFrontier failed: %v", err)
+	}
+	c := crawler.NewStandardCrawler(&MockCollector{}, crawler.NewInMemoryRegistry(), 1,
+		crawler.WithSitemap(sm), crawler.WithFrontier(frontier))
+
+	// Hold the crawl until the sitemap has been queued alongside the start URL
+	c.Pause()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go c.Start(ctx, ts.URL+"/")
+	for frontier.Len() < 4 {
+		if ctx.Err() != nil {
+			t.Fatal("Timed out waiting for the sitemap")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Resume()
+
+	var urls []string
+	for res := range c.Results() {
+		urls = append(urls, res.URL)
+	}
+	want := []string{ts.URL + "/high", ts.URL + "/default", ts.URL + "/low", ts.URL + "/"}
+	if !slices.Equal(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+}
@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitedCollector wraps a Collector with a token bucket per host, shared
+// by every worker using it. The rate for a host is halved whenever it responds
+// with 429 or 503, and recovers gradually on successful responses.
+type RateLimitedCollector struct {
+	collector Collector
+	rps       float64
+	burst     int
+
+	mu    sync.Mutex
+	hosts map[string]*bucket
+}
+
+// bucket is the token bucket for a single host
+type bucket struct {
+	rate   float64 // Current tokens per second
+	tokens float64
+	last   time.Time
+}
+
+// minRateFraction is the lowest a backed-off rate can fall, relative to the configured rate
+const minRateFraction = 1.0 / 16
+
+// NewRateLimitedCollector creates a RateLimitedCollector allowing rps requests
+// per second to each host, with bursts of up to burst requests
+func NewRateLimitedCollector(collector Collector, rps float64, burst int) *RateLimitedCollector {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimitedCollector{
+		collector: collector,
+		rps:       rps,
+		burst:     burst,
+		hosts:     make(map[string]*bucket),
+	}
+}
+
+// Collect waits for a token for the target's host, then collects it
+func (c *RateLimitedCollector) Collect(ctx context.Context, targetURL string) (*Resource, error) {
+	host := targetURL
+	if u, err := url.Parse(targetURL); err == nil {
+		host = u.Host
+	}
+
+	if err := c.wait(ctx, host); err != nil {
+		return nil, err
+	}
+
+	res, err := c.collector.Collect(ctx, targetURL)
+	if res != nil {
+		c.adapt(host, res.Status)
+	}
+	return res, err
+}
+
+// wait reserves a token, sleeping until it is available
+func (c *RateLimitedCollector) wait(ctx context.Context, host string) error {
+	c.mu.Lock()
+	b, ok := c.hosts[host]
+	if !ok {
+		b = &bucket{rate: c.rps, tokens: float64(c.burst), last: time.Now()}
+		c.hosts[host] = b
+	}
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > float64(c.burst) {
+		b.tokens = float64(c.burst)
+	}
+	b.last = now
+
+	// Take the token now, even if that leaves a deficit, so concurrent
+	// callers queue up behind each other instead of racing for the refill
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	c.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// adapt slows a host down when it signals overload and speeds it back up otherwise
+func (c *RateLimitedCollector) adapt(host, status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.hosts[host]
+	if !ok {
+		return
+	}
+
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return
+	}
+	switch {
+	case code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable:
+		b.rate /= 2
+		if minRate := c.rps * minRateFraction; b.rate < minRate {
+			b.rate = minRate
+		}
+	case code < 400:
+		b.rate += c.rps * 0.1
+		if b.rate > c.rps {
+			b.rate = c.rps
+		}
+	}
+}
+
+// Rate returns the configured requests per second per host
+func (c *RateLimitedCollector) Rate() float64 {
+	return c.rps
+}
+
+// Pacing returns the current requests per second of the slowest host, which
+// is below Rate while a host is being backed off
+func (c *RateLimitedCollector) Pacing() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pacing := c.rps
+	for _, b := range c.hosts {
+		if b.rate < pacing {
+			pacing = b.rate
+		}
+	}
+	return pacing
+}
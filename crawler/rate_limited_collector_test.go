@@ -0,0 +1,78 @@
+package crawler_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+type StatusCollector struct {
+	Status string
+}
+
+func (c *StatusCollector) Collect(ctx context.Context, url string) (*crawler.Resource, error) {
+	return &crawler.Resource{URL: url, Status: c.Status}, nil
+}
+
+func TestRateLimitedCollector_SpacesRequests(t *testing.T) {
+	rc := crawler.NewRateLimitedCollector(&StatusCollector{Status: "200"}, 20, 1)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rc.Collect(context.Background(), "http://example.com/")
+		}()
+	}
+	wg.Wait()
+
+	// The first request uses the burst token, the other four wait 50ms each
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("Expected requests to be spaced out, took %s", elapsed)
+	}
+}
+
+func TestRateLimitedCollector_PerHost(t *testing.T) {
+	rc := crawler.NewRateLimitedCollector(&StatusCollector{Status: "200"}, 1, 1)
+
+	start := time.Now()
+	rc.Collect(context.Background(), "http://a.example.com/")
+	rc.Collect(context.Background(), "http://b.example.com/")
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected different hosts not to share a bucket, took %s", elapsed)
+	}
+}
+
+func TestRateLimitedCollector_BacksOff(t *testing.T) {
+	sc := &StatusCollector{Status: "429"}
+	rc := crawler.NewRateLimitedCollector(sc, 100, 10)
+
+	rc.Collect(context.Background(), "http://example.com/")
+	if pacing := rc.Pacing(); pacing != 50 {
+		t.Errorf("Expected pacing to halve to 50, got %v", pacing)
+	}
+
+	sc.Status = "200"
+	rc.Collect(context.Background(), "http://example.com/")
+	if pacing := rc.Pacing(); pacing != 60 {
+		t.Errorf("Expected pacing to recover to 60, got %v", pacing)
+	}
+}
+
+func TestRateLimitedCollector_ContextCancel(t *testing.T) {
+	rc := crawler.NewRateLimitedCollector(&StatusCollector{Status: "200"}, 0.1, 1)
+	rc.Collect(context.Background(), "http://example.com/")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := rc.Collect(ctx, "http://example.com/"); err == nil {
+		t.Error("Expected an error when the context is cancelled while waiting")
+	}
+}
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Redirect issues flagged on a Resource
+const (
+	RedirectLoop      = "redirect loop"
+	RedirectTooLong   = "long redirect chain"
+	RedirectDowngrade = "https downgrade"
+)
+
+// DefaultMaxRedirects is the chain length above which RedirectTooLong is flagged
+const DefaultMaxRedirects = 5
+
+// maxRedirectHops is the most redirects a collector follows before giving up
+const maxRedirectHops = 20
+
+// Redirect is a single hop in a redirect chain
+type Redirect struct {
+	URL      string `json:"url"`
+	Status   string `json:"status"`
+	Location string `json:"location"` // Resolved absolute URL the hop points to
+}
+
+// noFollowClient returns a copy of client that returns redirect responses
+// instead of following them
+func noFollowClient(client *http.Client) *http.Client {
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &c
+}
+
+// followRedirects performs the request, following redirects by hand so each
+// hop can be recorded. It stops at the first non-redirect response, when a
+// URL repeats, or after maxRedirectHops, and returns the last response. Every
+// request sends the User-Agent carried by ctx.
+func followRedirects(ctx context.Context, client *http.Client, method, targetURL string) (*http.Response, []Redirect, error) {
+	userAgent := userAgentFrom(ctx)
+	var hops []Redirect
+	seen := map[string]bool{targetURL: true}
+	current := targetURL
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, method, current, nil)
+		if err != nil {
+			return nil, hops, err
+		}
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, hops, err
+		}
+
+		loc := resp.Header.Get("Location")
+		if !isRedirectStatus(resp.StatusCode) || loc == "" {
+			return resp, hops, nil
+		}
+
+		next, err := resp.Request.URL.Parse(loc)
+		if err != nil {
+			return resp, hops, nil
+		}
+		next.Fragment = ""
+		hops = append(hops, Redirect{
+			URL:      current,
+			Status:   fmt.Sprintf("%d", resp.StatusCode),
+			Location: next.String(),
+		})
+
+		if seen[next.String()] || len(hops) >= maxRedirectHops {
+			return resp, hops, nil
+		}
+		resp.Body.Close()
+		seen[next.String()] = true
+		current = next.String()
+	}
+}
+
+func isRedirectStatus(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// redirectIssues flags loops, chains longer than max and https to http
+// downgrades in a redirect chain. A max of 0 disables the length check.
+func redirectIssues(hops []Redirect, max int) []string {
+	if len(hops) == 0 {
+		return nil
+	}
+
+	var issues []string
+	last := hops[len(hops)-1].Location
+	for _, h := range hops {
+		if h.URL == last {
+			issues = append(issues, RedirectLoop)
+			break
+		}
+	}
+	if max > 0 && len(hops) > max {
+		issues = append(issues, RedirectTooLong)
+	}
+	for _, h := range hops {
+		from, err1 := url.Parse(h.URL)
+		to, err2 := url.Parse(h.Location)
+		if err1 == nil && err2 == nil && from.Scheme == "https" && to.Scheme == "http" {
+			issues = append(issues, RedirectDowngrade)
+			break
+		}
+	}
+	return issues
+}
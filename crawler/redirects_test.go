@@ -0,0 +1,149 @@
+package crawler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func newRedirectServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<a href="/old">old</a><a href="/new">new</a><a href="/loop">loop</a>`))
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/older", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/older", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<a href="/">home</a>`))
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop2", http.StatusFound)
+	})
+	mux.HandleFunc("/loop2", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestStaticCollector_RecordsRedirects(t *testing.T) {
+	ts := newRedirectServer()
+	defer ts.Close()
+
+	res, err := crawler.NewStaticCollector().Collect(context.Background(), ts.URL+"/old")
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if res.Status != "200" || res.FinalURL != ts.URL+"/new" {
+		t.Errorf("Expected 200 at %s/new, got %s at %s", ts.URL, res.Status, res.FinalURL)
+	}
+
+	want := []crawler.Redirect{
+		{URL: ts.URL + "/old", Status: "301", Location: ts.URL + "/older"},
+		{URL: ts.URL + "/older", Status: "302", Location: ts.URL + "/new"},
+	}
+	if !slices.Equal(res.Redirects, want) {
+		t.Errorf("Expected chain %v, got %v", want, res.Redirects)
+	}
+	if len(res.Links) != 1 || res.Links[0] != ts.URL+"/" {
+		t.Errorf("Expected links resolved against the final URL, got %v", res.Links)
+	}
+}
+
+func TestStaticCollector_StopsRedirectLoop(t *testing.T) {
+	ts := newRedirectServer()
+	defer ts.Close()
+
+	res, err := crawler.NewStaticCollector().Collect(context.Background(), ts.URL+"/loop")
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if res.Status != "302" || len(res.Redirects) != 2 {
+		t.Errorf("Expected loop to stop after 2 hops on a 302, got %s after %d", res.Status, len(res.Redirects))
+	}
+}
+
+func TestStandardCrawler_Redirects(t *testing.T) {
+	ts := newRedirectServer()
+	defer ts.Close()
+
+	c := crawler.NewStandardCrawler(crawler.NewStaticCollector(), crawler.NewInMemoryRegistry(), 2,
+		crawler.WithMaxRedirects(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go c.Start(ctx, ts.URL+"/")
+
+	results := make(map[string][]crawler.Resource)
+	for res := range c.Results() {
+		results[res.URL] = append(results[res.URL], res)
+	}
+
+	if n := len(results[ts.URL+"/new"]); n != 1 {
+		t.Errorf("Expected redirect target to be reported once, got %d", n)
+	}
+
+	old := results[ts.URL+"/old"]
+	if len(old) != 1 || old[0].Kind != crawler.KindRedirect || old[0].Status != "301" {
+		t.Fatalf("Expected /old to be reported as a 301 redirect, got %v", old)
+	}
+	if !slices.Contains(old[0].RedirectIssues, crawler.RedirectTooLong) {
+		t.Errorf("Expected 2-hop chain to exceed a limit of 1, got %v", old[0].RedirectIssues)
+	}
+
+	loop := results[ts.URL+"/loop"]
+	if len(loop) != 1 || !slices.Contains(loop[0].RedirectIssues, crawler.RedirectLoop) {
+		t.Errorf("Expected /loop to be flagged as a loop, got %v", loop)
+	}
+	if len(results[ts.URL+"/loop2"]) != 0 {
+		t.Errorf("Expected no page to be reported for a loop, got %v", results[ts.URL+"/loop2"])
+	}
+}
+
+// RedirectingCollector reports every URL as having redirected through Chain
+type RedirectingCollector struct {
+	Chain []crawler.Redirect
+}
+
+func (r *RedirectingCollector) Collect(ctx context.Context, targetURL string) (*crawler.Resource, error) {
+	return &crawler.Resource{
+		URL:       targetURL,
+		Status:    "200",
+		Kind:      "document",
+		Redirects: r.Chain,
+		FinalURL:  r.Chain[len(r.Chain)-1].Location,
+	}, nil
+}
+
+func TestStandardCrawler_FlagsDowngrade(t *testing.T) {
+	collector := &RedirectingCollector{Chain: []crawler.Redirect{
+		{URL: "http://example.com/", Status: "301", Location: "https://example.com/"},
+		{URL: "https://example.com/", Status: "301", Location: "http://example.com/home"},
+	}}
+	c := crawler.NewStandardCrawler(collector, crawler.NewInMemoryRegistry(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go c.Start(ctx, "http://example.com/")
+
+	var urls []string
+	for res := range c.Results() {
+		urls = append(urls, res.URL)
+		if res.Kind == crawler.KindRedirect && !slices.Contains(res.RedirectIssues, crawler.RedirectDowngrade) {
+			t.Errorf("Expected downgrade to be flagged, got %v", res.RedirectIssues)
+		}
+	}
+	if !slices.Equal(urls, []string{"http://example.com/", "http://example.com/home"}) {
+		t.Errorf("Expected the redirect and its target, got %v", urls)
+	}
+}
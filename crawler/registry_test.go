@@ -1,7 +1,10 @@
 package crawler_test
 
 import (
+	"fmt"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/jturmel/huntsman/crawler"
@@ -64,3 +67,152 @@ func TestInMemoryRegistry_Concurrency(t *testing.T) {
 		t.Errorf("Expected exactly 1 successful visit, got %d", count)
 	}
 }
+
+func TestDiskRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "visited.db")
+	registry, err := crawler.NewDiskRegistry(path)
+	if err != nil {
+		t.Fatalf("NewDiskRegistry failed: %v", err)
+	}
+
+	if !registry.Visit("http://example.com") {
+		t.Error("First visit should return true")
+	}
+	if registry.Visit("http://example.com") {
+		t.Error("Second visit should return false")
+	}
+	if !registry.IsVisited("http://example.com") || registry.IsVisited("http://example.org") {
+		t.Error("Expected IsVisited to reflect visits")
+	}
+	if err := registry.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Visits survive reopening the file
+	registry, err = crawler.NewDiskRegistry(path)
+	if err != nil {
+		t.Fatalf("Reopening failed: %v", err)
+	}
+	defer registry.Close()
+	if registry.Visit("http://example.com") {
+		t.Error("Expected the visit to be kept on disk")
+	}
+}
+
+func TestDiskRegistry_Concurrency(t *testing.T) {
+	registry, err := crawler.NewDiskRegistry(filepath.Join(t.TempDir(), "visited.db"))
+	if err != nil {
+		t.Fatalf("NewDiskRegistry failed: %v", err)
+	}
+	defer registry.Close()
+
+	var wg sync.WaitGroup
+	var count atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if registry.Visit("http://example.com") {
+				count.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count.Load() != 1 {
+		t.Errorf("Expected exactly 1 successful visit, got %d", count.Load())
+	}
+}
+
+func TestDiskRegistry_Err(t *testing.T) {
+	registry, err := crawler.NewDiskRegistry(filepath.Join(t.TempDir(), "visited.db"))
+	if err != nil {
+		t.Fatalf("NewDiskRegistry failed: %v", err)
+	}
+
+	registry.Visit("http://example.com")
+	if err := registry.Err(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// bbolt rejects empty keys, so the URL can't be recorded and is
+	// reported as already visited
+	if registry.Visit("") {
+		t.Error("Expected a URL that can't be recorded to count as visited")
+	}
+	first := registry.Err()
+	if first == nil {
+		t.Fatal("Expected the failed write to be reported")
+	}
+
+	// Only the first error is kept
+	registry.Close()
+	if registry.Visit("http://example.org") {
+		t.Error("Expected visits to a closed registry to count as visited")
+	}
+	if err := registry.Err(); err != first {
+		t.Errorf("Expected the first error to be kept, got %v", err)
+	}
+}
+
+func TestBloomRegistry(t *testing.T) {
+	const n = 10000
+	registry, err := crawler.NewBloomRegistry(n, 0.01)
+	if err != nil {
+		t.Fatalf("NewBloomRegistry failed: %v", err)
+	}
+
+	falsePositives := 0
+	for i := range n {
+		u := fmt.Sprintf("http://example.com/page/%d", i)
+		if !registry.Visit(u) {
+			falsePositives++
+		}
+		if registry.Visit(u) {
+			t.Fatalf("Second visit to %s should return false", u)
+		}
+		if !registry.IsVisited(u) {
+			t.Fatalf("Expected %s to be visited", u)
+		}
+	}
+
+	// Allow generous slack over the 1% target
+	if falsePositives > n/50 {
+		t.Errorf("Expected about 1%% false positives, got %d of %d", falsePositives, n)
+	}
+
+	if _, err := crawler.NewBloomRegistry(n, 1.5); err == nil {
+		t.Error("Expected an invalid false positive rate to be rejected")
+	}
+}
+
+// BenchmarkDiskRegistry_Visit visits new URLs from one goroutine, as a worker
+// does for the links on a page
+func BenchmarkDiskRegistry_Visit(b *testing.B) {
+	r, err := crawler.NewDiskRegistry(filepath.Join(b.TempDir(), "visited.db"))
+	if err != nil {
+		b.Fatalf("NewDiskRegistry failed: %v", err)
+	}
+	defer r.Close()
+	for i := 0; i < b.N; i++ {
+		r.Visit(fmt.Sprintf("https://example.com/page/%d", i))
+	}
+}
+
+// BenchmarkDiskRegistry_VisitParallel visits from many goroutines, with
+// three in four URLs already visited
+func BenchmarkDiskRegistry_VisitParallel(b *testing.B) {
+	r, err := crawler.NewDiskRegistry(filepath.Join(b.TempDir(), "visited.db"))
+	if err != nil {
+		b.Fatalf("NewDiskRegistry failed: %v", err)
+	}
+	defer r.Close()
+
+	var n atomic.Int64
+	b.SetParallelism(8)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r.Visit(fmt.Sprintf("https://example.com/page/%d", n.Add(1)/4))
+		}
+	})
+}
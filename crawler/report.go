@@ -0,0 +1,317 @@
+package crawler
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed report.html.tmpl
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"size": formatBytes,
+}).Parse(reportHTML))
+
+// Report summarizes a finished crawl for sharing outside the TUI
+type Report struct {
+	Info      CrawlInfo
+	Resources []Resource
+	Sitemap   []string // URLs listed in the site's sitemaps, if they were read
+	links     *LinkIndex
+}
+
+// Count is a label together with how many resources have it
+type Count struct {
+	Label string
+	Count int
+}
+
+// BrokenLink is a resource that failed to load along with every page linking to it
+type BrokenLink struct {
+	Resource  Resource
+	Referrers []string
+}
+
+// TreeNode is one path segment in the site tree. URL is empty for segments
+// that were never crawled themselves.
+type TreeNode struct {
+	Name     string
+	URL      string
+	Status   string
+	Children []*TreeNode
+}
+
+// NewReport creates a report over resources. links may be nil, in which case
+// each resource's FromSource is used as its only referrer.
+func NewReport(info CrawlInfo, resources []Resource, links *LinkIndex) *Report {
+	return &Report{Info: info, Resources: resources, links: links}
+}
+
+// StatusCounts returns the number of resources per Status, sorted by status
+func (r *Report) StatusCounts() []Count {
+	return r.countBy(func(res Resource) string { return res.Status })
+}
+
+// KindCounts returns the number of resources per Kind, sorted by kind
+func (r *Report) KindCounts() []Count {
+	return r.countBy(func(res Resource) string { return res.Kind })
+}
+
+func (r *Report) countBy(key func(Resource) string) []Count {
+	counts := make(map[string]int)
+	for _, res := range r.Resources {
+		counts[key(res)]++
+	}
+	out := make([]Count, 0, len(counts))
+	for label, n := range counts {
+		out = append(out, Count{Label: label, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Label < out[j].Label })
+	return out
+}
+
+// Broken returns every resource with a 4xx or 5xx status or that couldn't be fetched
+func (r *Report) Broken() []BrokenLink {
+	var out []BrokenLink
+	for _, res := range r.Resources {
+		if !isBroken(res.Status) {
+			continue
+		}
+		var refs []string
+		if r.links != nil {
+			refs = r.links.Referrers(res.URL)
+		}
+		if len(refs) == 0 && res.FromSource != "" {
+			refs = []string{res.FromSource}
+		}
+		out = append(out, BrokenLink{Resource: res, Referrers: refs})
+	}
+	return out
+}
+
+// isBroken reports whether status is a 4xx/5xx code or a fetch error
+func isBroken(status string) bool {
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return status == "Error" || status == "Read Err"
+	}
+	return code >= 400
+}
+
+// Orphans returns sitemap URLs that no crawled page links to
+func (r *Report) Orphans() []string {
+	reached := make(map[string]bool)
+	for _, res := range r.Resources {
+		// The start URL is reached by definition
+		if res.Depth == 0 && res.FromSource == "" {
+			reached[res.URL] = true
+		}
+		if r.links == nil && res.FromSource != "" && res.FromSource != SourceSitemap {
+			reached[res.URL] = true
+		}
+	}
+
+	var out []string
+	for _, u := range r.Sitemap {
+		if reached[u] {
+			continue
+		}
+		if r.links != nil && slices.ContainsFunc(r.links.Referrers(u), func(ref string) bool { return ref != u }) {
+			continue
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+// NotInSitemap returns indexable pages that were crawled but aren't listed
+// in the sitemap. It returns nil when no sitemap was read.
+func (r *Report) NotInSitemap() []Resource {
+	if len(r.Sitemap) == 0 {
+		return nil
+	}
+	listed := make(map[string]bool, len(r.Sitemap))
+	for _, u := range r.Sitemap {
+		listed[u] = true
+	}
+
+	var out []Resource
+	for _, res := range r.Resources {
+		if res.Kind == "document" && res.Status == "200" && !res.External && !res.NoIndex() && !listed[res.URL] {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Tree arranges crawled documents by host and URL path
+func (r *Report) Tree() []*TreeNode {
+	roots := make(map[string]*TreeNode)
+	var hosts []string
+
+	for _, res := range r.Resources {
+		if res.Kind != "document" {
+			continue
+		}
+		u, err := url.Parse(res.URL)
+		if err != nil {
+			continue
+		}
+
+		root, ok := roots[u.Host]
+		if !ok {
+			root = &TreeNode{Name: u.Host}
+			roots[u.Host] = root
+			hosts = append(hosts, u.Host)
+		}
+
+		node := root
+		path := strings.Trim(u.Path, "/")
+		if path != "" {
+			for _, seg := range strings.Split(path, "/") {
+				node = node.child(seg)
+			}
+		}
+		if u.RawQuery != "" {
+			node = node.child("?" + u.RawQuery)
+		}
+		node.URL = res.URL
+		node.Status = res.Status
+	}
+
+	sort.Strings(hosts)
+	out := make([]*TreeNode, 0, len(hosts))
+	for _, h := range hosts {
+		roots[h].sort()
+		out = append(out, roots[h])
+	}
+	return out
+}
+
+func (n *TreeNode) child(name string) *TreeNode {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	c := &TreeNode{Name: name}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+func (n *TreeNode) sort() {
+	sort.Slice(n.Children, func(i, j int) bool { return n.Children[i].Name < n.Children[j].Name })
+	for _, c := range n.Children {
+		c.sort()
+	}
+}
+
+// WriteMarkdown writes the report as Markdown
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "# Crawl report: %s\n\n", r.Info.StartURL)
+	if !r.Info.StartedAt.IsZero() {
+		fmt.Fprintf(b, "Crawled %d resources", len(r.Resources))
+		if !r.Info.FinishedAt.IsZero() {
+			fmt.Fprintf(b, " in %s", r.Info.FinishedAt.Sub(r.Info.StartedAt).Round(time.Millisecond))
+		}
+		fmt.Fprintf(b, ", started %s.\n\n", r.Info.StartedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	b.WriteString("## Summary\n\n")
+	writeCountTable(b, "Status", r.StatusCounts())
+	writeCountTable(b, "Type", r.KindCounts())
+
+	b.WriteString("## Broken links\n\n")
+	broken := r.Broken()
+	if len(broken) == 0 {
+		b.WriteString("No broken links found.\n\n")
+	} else {
+		b.WriteString("| Status | URL | Linked from |\n| --- | --- | --- |\n")
+		for _, bl := range broken {
+			refs := make([]string, len(bl.Referrers))
+			for i, ref := range bl.Referrers {
+				refs[i] = mdEscape(ref)
+			}
+			fmt.Fprintf(b, "| %s | %s | %s |\n", mdEscape(bl.Resource.Status), mdEscape(bl.Resource.URL), strings.Join(refs, "<br>"))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Sitemap) > 0 {
+		b.WriteString("## Orphan pages\n\nListed in the sitemap but not linked from any crawled page.\n\n")
+		writeURLList(b, r.Orphans(), "No orphan pages found.")
+
+		b.WriteString("## Missing from sitemap\n\nCrawled pages that the sitemap doesn't list.\n\n")
+		var missing []string
+		for _, res := range r.NotInSitemap() {
+			missing = append(missing, res.URL)
+		}
+		writeURLList(b, missing, "Every crawled page is in the sitemap.")
+	}
+
+	b.WriteString("## Site tree\n\n")
+	for _, root := range r.Tree() {
+		writeTree(b, root, 0)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeCountTable(b *strings.Builder, label string, counts []Count) {
+	fmt.Fprintf(b, "| %s | Count |\n| --- | ---: |\n", label)
+	for _, c := range counts {
+		fmt.Fprintf(b, "| %s | %d |\n", mdEscape(c.Label), c.Count)
+	}
+	b.WriteString("\n")
+}
+
+func writeURLList(b *strings.Builder, urls []string, empty string) {
+	if len(urls) == 0 {
+		fmt.Fprintf(b, "%s\n\n", empty)
+		return
+	}
+	for _, u := range urls {
+		fmt.Fprintf(b, "- %s\n", mdEscape(u))
+	}
+	b.WriteString("\n")
+}
+
+func writeTree(b *strings.Builder, n *TreeNode, indent int) {
+	b.WriteString(strings.Repeat("  ", indent))
+	if n.URL != "" {
+		fmt.Fprintf(b, "- [%s](%s)", mdEscape(n.Name), n.URL)
+		if n.Status != "200" {
+			fmt.Fprintf(b, " (%s)", n.Status)
+		}
+	} else {
+		fmt.Fprintf(b, "- %s", mdEscape(n.Name))
+	}
+	b.WriteString("\n")
+	for _, c := range n.Children {
+		writeTree(b, c, indent+1)
+	}
+}
+
+// mdEscape escapes characters that would break Markdown tables and links
+var mdEscape = strings.NewReplacer("|", `\|`, "[", `\[`, "]", `\]`).Replace
+
+// WriteHTML writes the report as a single self-contained HTML page with sortable tables
+func (r *Report) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
+
+// formatBytes renders a byte count in kB, matching the TUI
+func formatBytes(size int64) string {
+	return fmt.Sprintf("%.1f kB", float64(size)/1024.0)
+}
@@ -0,0 +1,76 @@
+package crawler_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func testReport() *crawler.Report {
+	links := crawler.NewLinkIndex()
+	links.AddLinks("http://example.com/", []string{"http://example.com/docs/missing"})
+	links.AddLinks("http://example.com/docs/", []string{"http://example.com/docs/missing"})
+
+	resources := []crawler.Resource{
+		{URL: "http://example.com/", Status: "200", Kind: "document"},
+		{URL: "http://example.com/docs/", Status: "200", Kind: "document", FromSource: "http://example.com/"},
+		{URL: "http://example.com/docs/missing", Status: "404", Kind: "document", FromSource: "http://example.com/"},
+		{URL: "http://example.com/app.js", Status: "200", Kind: "script", FromSource: "http://example.com/"},
+	}
+	return crawler.NewReport(crawler.CrawlInfo{StartURL: "http://example.com/"}, resources, links)
+}
+
+func TestReport_Broken(t *testing.T) {
+	broken := testReport().Broken()
+	if len(broken) != 1 {
+		t.Fatalf("Expected 1 broken link, got %d", len(broken))
+	}
+	if len(broken[0].Referrers) != 2 {
+		t.Errorf("Expected 2 referrers, got %v", broken[0].Referrers)
+	}
+}
+
+func TestReport_Tree(t *testing.T) {
+	tree := testReport().Tree()
+	if len(tree) != 1 || tree[0].Name != "example.com" {
+		t.Fatalf("Expected a single example.com root, got %v", tree)
+	}
+	docs := tree[0].Children
+	if len(docs) != 1 || docs[0].Name != "docs" {
+		t.Fatalf("Expected docs child, got %v", docs)
+	}
+	if len(docs[0].Children) != 1 || docs[0].Children[0].Status != "404" {
+		t.Errorf("Expected missing page under docs, got %v", docs[0].Children)
+	}
+}
+
+func TestReport_WriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"| 404 | 1 |", "| script | 1 |", "http://example.com/docs/missing", "- [docs](http://example.com/docs/)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected Markdown to contain %q", want)
+		}
+	}
+}
+
+func TestReport_WriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteHTML(&buf); err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `<table class="sortable">`) {
+		t.Error("Expected sortable tables in HTML report")
+	}
+	if !strings.Contains(out, "http://example.com/docs/missing") {
+		t.Error("Expected broken link in HTML report")
+	}
+}
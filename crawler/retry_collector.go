@@ -31,6 +31,10 @@ func (c *RetryCollector) Collect(ctx context.Context, targetURL string) (*Resour
 			return res, nil
 		}
 
+		if i < c.retries {
+			notifyRetry(ctx, i+1, err)
+		}
+
 		// If error, check context. If cancelled, abort.
 		select {
 		case <-ctx.Done():
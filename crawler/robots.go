@@ -0,0 +1,299 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusBlockedByRobots marks URLs skipped because robots.txt disallows them
+const StatusBlockedByRobots = "Blocked by robots"
+
+// DefaultUserAgent is the product token huntsman sends and matches against
+// robots.txt groups
+const DefaultUserAgent = "huntsman"
+
+// maxRobotsSize is the most of a robots.txt file that is parsed, per RFC 9309
+const maxRobotsSize = 500 * 1024
+
+// Robots fetches, caches and evaluates robots.txt files per host
+type Robots struct {
+	client    *http.Client
+	userAgent string
+
+	mu    sync.Mutex
+	hosts map[string]*robotsHost
+}
+
+// robotsHost is the cached state for a single scheme and host
+type robotsHost struct {
+	fetchMu  sync.Mutex
+	rules    *RobotsRules // Nil until a fetch succeeds
+	mu       sync.Mutex
+	lastSeen time.Time
+}
+
+// NewRobots creates a Robots that evaluates rules for userAgent
+func NewRobots(userAgent string) *Robots {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &Robots{
+		client:    &http.Client{Timeout: 10 * time.Second},
+		userAgent: userAgent,
+		hosts:     make(map[string]*robotsHost),
+	}
+}
+
+// Allowed reports whether the user agent may fetch u
+func (r *Robots) Allowed(ctx context.Context, u *url.URL) bool {
+	return r.Rules(ctx, u).Allowed(robotsPath(u))
+}
+
+// Rules returns the parsed robots.txt for u's host. It is fetched once per
+// host; concurrent callers wait for the first fetch. While robots.txt is
+// unreachable everything is disallowed, and the next call tries again.
+func (r *Robots) Rules(ctx context.Context, u *url.URL) *RobotsRules {
+	h := r.host(u)
+	h.fetchMu.Lock()
+	defer h.fetchMu.Unlock()
+	if h.rules != nil {
+		return h.rules
+	}
+	rules, ok := r.fetch(ctx, u.Scheme+"://"+u.Host)
+	if ok {
+		h.rules = rules
+	}
+	return rules
+}
+
+// Wait blocks until the host's Crawl-delay has passed since the previous
+// request to it, then records the new request time.
+func (r *Robots) Wait(ctx context.Context, u *url.URL) error {
+	rules := r.Rules(ctx, u)
+	if rules.CrawlDelay <= 0 {
+		return nil
+	}
+
+	h := r.host(u)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if wait := time.Until(h.lastSeen.Add(rules.CrawlDelay)); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	h.lastSeen = time.Now()
+	return nil
+}
+
+func (r *Robots) host(u *url.URL) *robotsHost {
+	key := u.Scheme + "://" + u.Host
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.hosts[key]
+	if !ok {
+		h = &robotsHost{}
+		r.hosts[key] = h
+	}
+	return h
+}
+
+// fetch downloads and parses robots.txt. Per RFC 9309, a 4xx response means
+// everything is allowed and an unreachable file means everything is
+// disallowed. ok is false when the file was unreachable, so the rules
+// shouldn't be cached.
+func (r *Robots) fetch(ctx context.Context, origin string) (rules *RobotsRules, ok bool) {
+	req, err := http.NewRequestWithContext(ctx, "GET", origin+"/robots.txt", nil)
+	if err != nil {
+		return disallowAll(), false
+	}
+	req.Header.Set("User-Agent", r.userAgent)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return disallowAll(), false
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return ParseRobots(io.LimitReader(resp.Body, maxRobotsSize), r.userAgent), true
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &RobotsRules{}, true
+	default:
+		return disallowAll(), false
+	}
+}
+
+func disallowAll() *RobotsRules {
+	return &RobotsRules{rules: []robotsRule{{pattern: "/", allow: false}}}
+}
+
+// robotsPath returns the path and query robots rules are matched against
+func robotsPath(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+	if u.RawQuery != "" {
+		p += "?" + u.RawQuery
+	}
+	return p
+}
+
+// RobotsRules is the group of a robots.txt file that applies to one user agent
+type RobotsRules struct {
+	rules      []robotsRule
+	CrawlDelay time.Duration
+	Sitemaps   []string
+}
+
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// ParseRobots parses a robots.txt file and keeps the group that best matches
+// userAgent: the longest user-agent line contained in it, falling back to "*".
+func ParseRobots(r io.Reader, userAgent string) *RobotsRules {
+	type group struct {
+		agents []string
+		rules  []robotsRule
+		delay  time.Duration
+	}
+
+	var groups []*group
+	var current *group
+	var sitemaps []string
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{pattern: value, allow: key == "allow"})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+				current.delay = time.Duration(secs * float64(time.Second))
+			}
+		case "sitemap":
+			sitemaps = append(sitemaps, value)
+		}
+	}
+
+	ua := strings.ToLower(userAgent)
+	if token, _, ok := strings.Cut(ua, "/"); ok {
+		ua = token
+	}
+
+	// Find the most specific matching agent, merging groups that share it
+	best := ""
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a != "*" && a != "" && strings.Contains(ua, a) && len(a) > len(best) {
+				best = a
+			}
+		}
+	}
+	if best == "" {
+		best = "*"
+	}
+
+	rules := &RobotsRules{Sitemaps: sitemaps}
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a == best {
+				rules.rules = append(rules.rules, g.rules...)
+				if g.delay > rules.CrawlDelay {
+					rules.CrawlDelay = g.delay
+				}
+				break
+			}
+		}
+	}
+	return rules
+}
+
+// Allowed reports whether path may be fetched. The longest matching rule
+// wins, and Allow wins ties.
+func (r *RobotsRules) Allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	allowed := true
+	longest := -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		n := len(rule.pattern)
+		if n > longest || (n == longest && rule.allow) {
+			longest = n
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// matchRobotsPattern matches path against a robots.txt pattern, where * matches
+// any sequence of characters and a trailing $ anchors the end of the path
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			// The last segment must sit at the very end of the path
+			return strings.HasSuffix(path[pos:], part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	return !anchored || pos == len(path)
+}
@@ -0,0 +1,232 @@
+package crawler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+const testRobots = `
+# Comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public.html
+
+User-agent: huntsman
+Disallow: /admin
+Disallow: /*.pdf$
+Allow: /admin/help
+Crawl-delay: 0.5
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+func TestParseRobots_SpecificGroup(t *testing.T) {
+	rules := crawler.ParseRobots(strings.NewReader(testRobots), "huntsman/1.0")
+
+	tests := map[string]bool{
+		"/":                  true,
+		"/private/secret":    true, // Only the * group disallows this
+		"/admin":             false,
+		"/admin/users":       false,
+		"/admin/help":        true,
+		"/files/report.pdf":  false,
+		"/files/report.pdfx": true,
+	}
+	for path, want := range tests {
+		if got := rules.Allowed(path); got != want {
+			t.Errorf("Allowed(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	if rules.CrawlDelay != 500*time.Millisecond {
+		t.Errorf("Expected crawl delay 500ms, got %s", rules.CrawlDelay)
+	}
+	if len(rules.Sitemaps) != 1 || rules.Sitemaps[0] != "https://example.com/sitemap.xml" {
+		t.Errorf("Expected sitemap to be recorded, got %v", rules.Sitemaps)
+	}
+}
+
+func TestParseRobots_WildcardGroup(t *testing.T) {
+	rules := crawler.ParseRobots(strings.NewReader(testRobots), "otherbot")
+
+	if rules.Allowed("/private/secret") {
+		t.Error("Expected /private/secret to be disallowed")
+	}
+	if !rules.Allowed("/private/public.html") {
+		t.Error("Expected the longer Allow rule to win")
+	}
+	if !rules.Allowed("/admin") {
+		t.Error("Expected /admin to be allowed for the * group")
+	}
+}
+
+func TestRobots_MissingFileAllowsAll(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	r := crawler.NewRobots("")
+	u, _ := url.Parse(ts.URL + "/anything")
+	if !r.Allowed(context.Background(), u) {
+		t.Error("Expected a missing robots.txt to allow everything")
+	}
+}
+
+func TestRobots_RetriesUnreachableFile(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer ts.Close()
+
+	r := crawler.NewRobots("")
+	u, _ := url.Parse(ts.URL + "/page")
+	if r.Allowed(context.Background(), u) {
+		t.Error("Expected an unreachable robots.txt to disallow everything")
+	}
+	down.Store(false)
+	if !r.Allowed(context.Background(), u) {
+		t.Error("Expected robots.txt to be fetched again once reachable")
+	}
+	down.Store(true)
+	if !r.Allowed(context.Background(), u) {
+		t.Error("Expected a fetched robots.txt to stay cached")
+	}
+}
+
+func TestStandardCrawler_UserAgent(t *testing.T) {
+	var mu sync.Mutex
+	agents := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents[r.Method+" "+r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<a href="/old">old</a><a href="/ext">ext</a>`))
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer ts.Close()
+
+	base, _ := url.Parse(ts.URL)
+	notExt, err := crawler.NewPatternScope(nil, []string{"**/ext"})
+	if err != nil {
+		t.Fatalf("NewPatternScope failed: %v", err)
+	}
+	c := crawler.NewStandardCrawler(crawler.NewStaticCollector(), crawler.NewInMemoryRegistry(), 1,
+		crawler.WithUserAgent("testbot/1.0"),
+		crawler.WithScope(crawler.AllOf(crawler.NewHostScope(base), notExt)),
+		crawler.WithExternalLinks(crawler.NewLinkChecker()),
+		crawler.WithRobots(crawler.NewRobots("testbot/1.0")),
+		crawler.WithSitemap(crawler.NewSitemap("testbot/1.0")),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go c.Start(ctx, ts.URL+"/")
+	for range c.Results() {
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, req := range []string{"GET /robots.txt", "GET /sitemap.xml", "GET /", "GET /old", "GET /new", "HEAD /ext"} {
+		if agents[req] != "testbot/1.0" {
+			t.Errorf("Expected %s to send the user agent, got %q", req, agents[req])
+		}
+	}
+}
+
+func TestStandardCrawler_Robots(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /blocked\n"))
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<a href="/blocked">b</a><a href="/nofollow">n</a>`))
+		case "/nofollow":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<meta name="robots" content="noindex, nofollow"><a href="/hidden">h</a>`))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer ts.Close()
+
+	c := crawler.NewStandardCrawler(crawler.NewStaticCollector(), crawler.NewInMemoryRegistry(), 2,
+		crawler.WithRobots(crawler.NewRobots("")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go c.Start(ctx, ts.URL+"/")
+
+	results := make(map[string]crawler.Resource)
+	for res := range c.Results() {
+		results[strings.TrimPrefix(res.URL, ts.URL)] = res
+	}
+
+	if results["/blocked"].Status != crawler.StatusBlockedByRobots {
+		t.Errorf("Expected /blocked to be reported as blocked, got %q", results["/blocked"].Status)
+	}
+	if !results["/nofollow"].NoIndex() {
+		t.Error("Expected /nofollow to carry noindex from its meta tag")
+	}
+	if _, ok := results["/hidden"]; ok {
+		t.Error("Expected links on a nofollow page not to be followed")
+	}
+}
+
+func TestStandardCrawler_XRobotsTagUserAgent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			w.Header().Add("X-Robots-Tag", "huntsman: nofollow")
+			w.Header().Add("X-Robots-Tag", "testbot: noindex")
+			w.Write([]byte(`<a href="/child">child</a>`))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c := crawler.NewStandardCrawler(crawler.NewStaticCollector(), crawler.NewInMemoryRegistry(), 1,
+		crawler.WithUserAgent("testbot/1.0"),
+		crawler.WithRobots(crawler.NewRobots("testbot/1.0")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream := c.Results()
+	go c.Start(ctx, ts.URL+"/")
+	results := make(map[string]crawler.Resource)
+	for res := range stream {
+		results[strings.TrimPrefix(res.URL, ts.URL)] = res
+	}
+
+	if !results["/"].NoIndex() {
+		t.Error("Expected noindex scoped to the configured user agent to apply")
+	}
+	if _, ok := results["/child"]; !ok {
+		t.Error("Expected nofollow scoped to another user agent to be ignored")
+	}
+}
@@ -0,0 +1,257 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Scope decides whether a discovered link should be crawled
+type Scope interface {
+	InScope(u *url.URL) bool
+}
+
+// HostScope allows URLs on exactly the same host (including port) as the base URL
+type HostScope struct {
+	host string
+}
+
+// NewHostScope creates a HostScope for the host of base
+func NewHostScope(base *url.URL) *HostScope {
+	return &HostScope{host: base.Host}
+}
+
+// InScope reports whether u is on the scope's host
+func (s *HostScope) InScope(u *url.URL) bool {
+	return u.Host == s.host
+}
+
+// PathScope allows URLs on the same host as the base URL whose path falls
+// under the base URL's directory
+type PathScope struct {
+	host   string
+	prefix string
+}
+
+// NewPathScope creates a PathScope rooted at the directory of base's path.
+// For example https://example.com/docs/intro limits the crawl to /docs/. A
+// top-level path like https://example.com/docs limits it to /docs rather
+// than the whole host.
+func NewPathScope(base *url.URL) *PathScope {
+	prefix := base.Path
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		prefix = prefix[:i+1]
+	} else if prefix == "" {
+		prefix = "/"
+	}
+	return &PathScope{host: base.Host, prefix: prefix}
+}
+
+// InScope reports whether u is on the scope's host and under its path prefix
+func (s *PathScope) InScope(u *url.URL) bool {
+	if u.Host != s.host {
+		return false
+	}
+	return underPath(u.Path, s.prefix)
+}
+
+// PrefixScope allows URLs whose path starts with a fixed prefix, on any host.
+// Combine it with AllOf to restrict another scope to a subtree.
+type PrefixScope struct {
+	prefix string
+}
+
+// NewPrefixScope creates a PrefixScope. A missing leading slash is added.
+func NewPrefixScope(prefix string) *PrefixScope {
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return &PrefixScope{prefix: prefix}
+}
+
+// InScope reports whether u's path is under the prefix. A prefix like /docs
+// matches /docs and /docs/intro but not /docsearch.
+func (s *PrefixScope) InScope(u *url.URL) bool {
+	return underPath(u.Path, s.prefix)
+}
+
+// underPath reports whether p is prefix or below it, only splitting at path
+// segment boundaries
+func underPath(p, prefix string) bool {
+	if p == "" {
+		p = "/"
+	}
+	if strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(p, prefix)
+	}
+	return p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+// DomainScope allows URLs on the same registrable domain as the base URL,
+// so example.com, www.example.com and docs.example.com are all in scope
+type DomainScope struct {
+	domain string
+}
+
+// NewDomainScope creates a DomainScope for the registrable domain of base,
+// as defined by the public suffix list
+func NewDomainScope(base *url.URL) *DomainScope {
+	return &DomainScope{domain: registrableDomain(base.Hostname())}
+}
+
+// InScope reports whether u shares the scope's registrable domain
+func (s *DomainScope) InScope(u *url.URL) bool {
+	return registrableDomain(u.Hostname()) == s.domain
+}
+
+// registrableDomain returns the eTLD+1 of host, or host itself for IPs,
+// single-label hosts like localhost, and bare public suffixes
+func registrableDomain(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// HostListScope allows URLs on any of a fixed set of hosts
+type HostListScope struct {
+	hosts map[string]bool
+}
+
+// NewHostListScope creates a HostListScope. Hosts may include a port, in
+// which case only that port matches.
+func NewHostListScope(hosts ...string) *HostListScope {
+	s := &HostListScope{hosts: make(map[string]bool)}
+	for _, h := range hosts {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			s.hosts[h] = true
+		}
+	}
+	return s
+}
+
+// InScope reports whether u's host is in the list
+func (s *HostListScope) InScope(u *url.URL) bool {
+	host := strings.ToLower(u.Host)
+	return s.hosts[host] || s.hosts[strings.ToLower(u.Hostname())]
+}
+
+// PatternScope filters URLs by include and exclude patterns matched against
+// the full URL. A URL is in scope if it matches at least one include pattern
+// (or there are none) and no exclude pattern.
+type PatternScope struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// NewPatternScope compiles include and exclude patterns with CompilePattern
+func NewPatternScope(include, exclude []string) (*PatternScope, error) {
+	s := &PatternScope{}
+	for _, p := range include {
+		re, err := CompilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		s.include = append(s.include, re)
+	}
+	for _, p := range exclude {
+		re, err := CompilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		s.exclude = append(s.exclude, re)
+	}
+	return s, nil
+}
+
+// InScope reports whether u passes the include and exclude patterns
+func (s *PatternScope) InScope(u *url.URL) bool {
+	raw := u.String()
+	for _, re := range s.exclude {
+		if re.MatchString(raw) {
+			return false
+		}
+	}
+	if len(s.include) == 0 {
+		return true
+	}
+	for _, re := range s.include {
+		if re.MatchString(raw) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompilePattern compiles a URL pattern. Patterns wrapped in slashes, like
+// /\.pdf$/, are regular expressions matched anywhere in the URL. Anything else
+// is a glob matched against the whole URL, where * matches any run of
+// characters except '/', ** matches anything, and ? matches one character.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		return re, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// allOf is a Scope that requires every one of its scopes to match
+type allOf []Scope
+
+// AllOf returns a Scope that allows URLs allowed by every one of scopes
+func AllOf(scopes ...Scope) Scope {
+	return allOf(scopes)
+}
+
+func (s allOf) InScope(u *url.URL) bool {
+	for _, scope := range s {
+		if !scope.InScope(u) {
+			return false
+		}
+	}
+	return true
+}
+
+// anyOf is a Scope that requires at least one of its scopes to match
+type anyOf []Scope
+
+// AnyOf returns a Scope that allows URLs allowed by any one of scopes
+func AnyOf(scopes ...Scope) Scope {
+	return anyOf(scopes)
+}
+
+func (s anyOf) InScope(u *url.URL) bool {
+	for _, scope := range s {
+		if scope.InScope(u) {
+			return true
+		}
+	}
+	return false
+}
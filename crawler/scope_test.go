@@ -0,0 +1,170 @@
+package crawler_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestHostScope_InScope(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs/")
+	s := crawler.NewHostScope(base)
+
+	tests := map[string]bool{
+		"https://example.com/":          true,
+		"https://example.com/blog/post": true,
+		"https://www.example.com/":      false,
+		"https://example.com:8443/":     false,
+		"https://other.com/":            false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := s.InScope(u); got != want {
+			t.Errorf("InScope(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestPathScope_InScope(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs/intro")
+	s := crawler.NewPathScope(base)
+
+	tests := map[string]bool{
+		"https://example.com/docs/":        true,
+		"https://example.com/docs/guide/a": true,
+		"https://example.com/docs":         false,
+		"https://example.com/blog/":        false,
+		"https://other.com/docs/intro":     false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := s.InScope(u); got != want {
+			t.Errorf("InScope(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestPathScope_TopLevelPath(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs")
+	s := crawler.NewPathScope(base)
+
+	tests := map[string]bool{
+		"https://example.com/docs":       true,
+		"https://example.com/docs/intro": true,
+		"https://example.com/docsearch":  false,
+		"https://example.com/":           false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := s.InScope(u); got != want {
+			t.Errorf("InScope(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestPrefixScope_InScope(t *testing.T) {
+	s := crawler.NewPrefixScope("/docs")
+
+	tests := map[string]bool{
+		"https://example.com/docs":        true,
+		"https://example.com/docs/":       true,
+		"https://cdn.example.com/docs/a":  true,
+		"https://example.com/docsearch":   false,
+		"https://example.com/docs-old/a":  false,
+		"https://example.com/blog/docs/a": false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := s.InScope(u); got != want {
+			t.Errorf("InScope(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestDomainScope_InScope(t *testing.T) {
+	base, _ := url.Parse("https://www.example.co.uk/")
+	s := crawler.NewDomainScope(base)
+
+	tests := map[string]bool{
+		"https://example.co.uk/":          true,
+		"https://docs.example.co.uk/a":    true,
+		"http://shop.example.co.uk:8080/": true,
+		"https://other.co.uk/":            false,
+		"https://example.com/":            false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := s.InScope(u); got != want {
+			t.Errorf("InScope(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestHostListScope_InScope(t *testing.T) {
+	s := crawler.NewHostListScope("cdn.example.com", "localhost:8080")
+
+	tests := map[string]bool{
+		"https://cdn.example.com/app.js": true,
+		"http://localhost:8080/":         true,
+		"http://localhost:9090/":         false,
+		"https://example.com/":           false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := s.InScope(u); got != want {
+			t.Errorf("InScope(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestPatternScope_InScope(t *testing.T) {
+	s, err := crawler.NewPatternScope(
+		[]string{"https://example.com/docs/**"},
+		[]string{`/\.pdf$/`, "**/print/*"},
+	)
+	if err != nil {
+		t.Fatalf("NewPatternScope failed: %v", err)
+	}
+
+	tests := map[string]bool{
+		"https://example.com/docs/intro":       true,
+		"https://example.com/docs/guide/setup": true,
+		"https://example.com/docs/manual.pdf":  false,
+		"https://example.com/docs/print/intro": false,
+		"https://example.com/blog/post":        false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := s.InScope(u); got != want {
+			t.Errorf("InScope(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestCompilePattern_Invalid(t *testing.T) {
+	if _, err := crawler.CompilePattern("/[/"); err == nil {
+		t.Error("Expected an error for an invalid regular expression")
+	}
+}
+
+func TestCombinedScopes(t *testing.T) {
+	base, _ := url.Parse("https://example.com/")
+	s := crawler.AnyOf(
+		crawler.AllOf(crawler.NewHostScope(base), crawler.NewPrefixScope("docs")),
+		crawler.NewHostListScope("cdn.example.com"),
+	)
+
+	tests := map[string]bool{
+		"https://example.com/docs/a":     true,
+		"https://example.com/blog":       false,
+		"https://cdn.example.com/lib.js": true,
+		"https://www.example.com/docs/a": false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := s.InScope(u); got != want {
+			t.Errorf("InScope(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package crawler
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SourceSitemap is the FromSource of pages seeded from a sitemap
+const SourceSitemap = "sitemap"
+
+// maxSitemapSize is the largest uncompressed sitemap read, per the sitemaps.org limit
+const maxSitemapSize = 50 * 1024 * 1024
+
+// defaultSitemapPriority is the priority of URLs that don't declare one
+const defaultSitemapPriority = 0.5
+
+// SitemapURL is a single <url> entry from a sitemap
+type SitemapURL struct {
+	Loc      string
+	LastMod  string
+	Priority float64
+}
+
+// Sitemap discovers and reads a site's sitemaps, and keeps the URLs seeded
+// from them so they can be compared against the crawl afterwards
+type Sitemap struct {
+	client    *http.Client
+	userAgent string
+	sources   []string
+
+	mu         sync.Mutex
+	urls       []SitemapURL
+	priorities map[string]float64
+	err        error
+}
+
+// NewSitemap creates a Sitemap that reads the given sitemap URLs, or
+// discovers them from robots.txt and /sitemap.xml when none are given.
+// userAgent is sent with every request and selects the robots.txt group,
+// though Sitemap lines apply to all.
+func NewSitemap(userAgent string, sources ...string) *Sitemap {
+	return &Sitemap{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		userAgent: userAgent,
+		sources:   sources,
+	}
+}
+
+// Discover returns the sitemaps listed in base's robots.txt, plus the
+// conventional /sitemap.xml
+func (s *Sitemap) Discover(ctx context.Context, base *url.URL) []string {
+	root := base.Scheme + "://" + base.Host
+	var found []string
+	if resp, err := s.get(ctx, root+"/robots.txt"); err == nil {
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			found = ParseRobots(io.LimitReader(resp.Body, maxRobotsSize), s.userAgent).Sitemaps
+		}
+		resp.Body.Close()
+	}
+
+	conventional := root + "/sitemap.xml"
+	for _, f := range found {
+		if f == conventional {
+			return found
+		}
+	}
+	return append(found, conventional)
+}
+
+// Load reads every sitemap for base, following sitemap indexes, and returns
+// the listed URLs without duplicates. Sitemaps that fail to load are
+// reported in the error, but the URLs from the others are still returned.
+// A missing /sitemap.xml found by discovery is not an error.
+func (s *Sitemap) Load(ctx context.Context, base *url.URL) ([]SitemapURL, error) {
+	sources := s.sources
+	discovered := len(sources) == 0
+	if discovered {
+		sources = s.Discover(ctx, base)
+	}
+
+	var urls []SitemapURL
+	var errs []error
+	seen := make(map[string]bool)
+	listed := make(map[string]bool)
+	for _, src := range sources {
+		// Discovery always tries /sitemap.xml, which many sites don't have
+		optional := discovered && src == base.Scheme+"://"+base.Host+"/sitemap.xml"
+		err := s.read(ctx, src, optional, seen, func(u SitemapURL) {
+			if !listed[u.Loc] {
+				listed[u.Loc] = true
+				urls = append(urls, u)
+			}
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return urls, errors.Join(errs...)
+}
+
+// URLs returns the URLs recorded by the crawler after loading the sitemaps
+func (s *Sitemap) URLs() []SitemapURL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SitemapURL(nil), s.urls...)
+}
+
+// Err returns the error from loading the sitemaps, if any
+func (s *Sitemap) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// record stores the result of Load for URLs and Err
+func (s *Sitemap) record(urls []SitemapURL, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.urls = urls
+	s.err = err
+	s.priorities = make(map[string]float64, len(urls))
+	for _, u := range urls {
+		s.priorities[u.Loc] = u.Priority
+	}
+}
+
+// priority returns the sitemap priority of loc, if the recorded URLs list it
+func (s *Sitemap) priority(loc string) (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	priority, ok := s.priorities[loc]
+	return priority, ok
+}
+
+// read fetches one sitemap, calling add for each URL and recursing into
+// child sitemaps of an index. seen guards against index cycles. An optional
+// sitemap that doesn't exist is not an error.
+func (s *Sitemap) read(ctx context.Context, loc string, optional bool, seen map[string]bool, add func(SitemapURL)) error {
+	if seen[loc] {
+		return nil
+	}
+	seen[loc] = true
+
+	resp, err := s.get(ctx, loc)
+	if err != nil {
+		return fmt.Errorf("sitemap %s: %w", loc, err)
+	}
+	defer resp.Body.Close()
+	if optional && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sitemap %s: status %d", loc, resp.StatusCode)
+	}
+
+	urls, children, err := ParseSitemap(resp.Body)
+	if err != nil {
+		return fmt.Errorf("sitemap %s: %w", loc, err)
+	}
+	for _, u := range urls {
+		add(u)
+	}
+
+	var errs []error
+	for _, child := range children {
+		if err := s.read(ctx, child, false, seen, add); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Sitemap) get(ctx context.Context, loc string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	userAgent := s.userAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	return s.client.Do(req)
+}
+
+// sitemapDocument matches both <urlset> and <sitemapindex> documents
+type sitemapDocument struct {
+	URLs []struct {
+		Loc      string `xml:"loc"`
+		LastMod  string `xml:"lastmod"`
+		Priority string `xml:"priority"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// ParseSitemap parses a sitemap or sitemap index, gzipped or not. It returns
+// the page URLs of a sitemap and the child sitemap URLs of an index.
+func ParseSitemap(r io.Reader) ([]SitemapURL, []string, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	var doc sitemapDocument
+	if err := xml.NewDecoder(io.LimitReader(r, maxSitemapSize)).Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+
+	var urls []SitemapURL
+	for _, u := range doc.URLs {
+		loc := strings.TrimSpace(u.Loc)
+		if loc == "" {
+			continue
+		}
+		priority, err := strconv.ParseFloat(strings.TrimSpace(u.Priority), 64)
+		if err != nil || priority < 0 || priority > 1 {
+			priority = defaultSitemapPriority
+		}
+		urls = append(urls, SitemapURL{Loc: loc, LastMod: strings.TrimSpace(u.LastMod), Priority: priority})
+	}
+
+	var children []string
+	for _, sm := range doc.Sitemaps {
+		if loc := strings.TrimSpace(sm.Loc); loc != "" {
+			children = append(children, loc)
+		}
+	}
+	return urls, children, nil
+}
@@ -0,0 +1,170 @@
+package crawler_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+const testURLSet = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/</loc><lastmod>2024-01-02</lastmod><priority>1.0</priority></url>
+  <url><loc> https://example.com/about </loc></url>
+</urlset>`
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(s))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseSitemap(t *testing.T) {
+	urls, children, err := crawler.ParseSitemap(strings.NewReader(testURLSet))
+	if err != nil {
+		t.Fatalf("ParseSitemap failed: %v", err)
+	}
+	want := []crawler.SitemapURL{
+		{Loc: "https://example.com/", LastMod: "2024-01-02", Priority: 1},
+		{Loc: "https://example.com/about", Priority: 0.5},
+	}
+	if !slices.Equal(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+	if len(children) != 0 {
+		t.Errorf("Expected no child sitemaps, got %v", children)
+	}
+}
+
+func TestParseSitemap_GzippedIndex(t *testing.T) {
+	index := `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/pages.xml</loc></sitemap>
+  <sitemap><loc>https://example.com/posts.xml.gz</loc></sitemap>
+</sitemapindex>`
+	urls, children, err := crawler.ParseSitemap(bytes.NewReader(gzipped(t, index)))
+	if err != nil {
+		t.Fatalf("ParseSitemap failed: %v", err)
+	}
+	if len(urls) != 0 {
+		t.Errorf("Expected no page URLs in an index, got %v", urls)
+	}
+	if !slices.Equal(children, []string{"https://example.com/pages.xml", "https://example.com/posts.xml.gz"}) {
+		t.Errorf("Unexpected child sitemaps %v", children)
+	}
+}
+
+// newSitemapServer serves a site whose robots.txt points at a gzipped
+// sitemap index. /orphan is only listed in the sitemap.
+func newSitemapServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	var ts *httptest.Server
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nSitemap: " + ts.URL + "/index.xml.gz\n"))
+	})
+	mux.HandleFunc("/index.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(gzipped(t, `<sitemapindex><sitemap><loc>`+ts.URL+`/pages.xml</loc></sitemap></sitemapindex>`))
+	})
+	mux.HandleFunc("/pages.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<urlset><url><loc>` + ts.URL + `/</loc></url><url><loc>` + ts.URL + `/a</loc></url><url><loc>` + ts.URL + `/orphan</loc></url></urlset>`))
+	})
+	mux.HandleFunc("/sitemap.xml", http.NotFound)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<a href="/a">a</a><a href="/b">b</a>`))
+		case "/a", "/b", "/orphan":
+			w.Write([]byte(`<a href="/">home</a>`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ts = httptest.NewServer(mux)
+	return ts
+}
+
+func TestSitemap_Load(t *testing.T) {
+	ts := newSitemapServer(t)
+	defer ts.Close()
+	base, _ := url.Parse(ts.URL)
+
+	sm := crawler.NewSitemap(crawler.DefaultUserAgent)
+	if found := sm.Discover(context.Background(), base); !slices.Equal(found, []string{ts.URL + "/index.xml.gz", ts.URL + "/sitemap.xml"}) {
+		t.Errorf("Unexpected discovered sitemaps %v", found)
+	}
+
+	urls, err := sm.Load(context.Background(), base)
+	if err != nil {
+		t.Fatalf("Expected missing /sitemap.xml to be ignored, got %v", err)
+	}
+	if len(urls) != 3 {
+		t.Errorf("Expected 3 URLs from the index, got %v", urls)
+	}
+
+	_, err = crawler.NewSitemap(crawler.DefaultUserAgent, ts.URL+"/sitemap.xml").Load(context.Background(), base)
+	if err == nil {
+		t.Error("Expected an explicitly requested missing sitemap to be an error")
+	}
+}
+
+func TestStandardCrawler_SeedsFromSitemap(t *testing.T) {
+	ts := newSitemapServer(t)
+	defer ts.Close()
+
+	links := crawler.NewLinkIndex()
+	sm := crawler.NewSitemap(crawler.DefaultUserAgent)
+	c := crawler.NewStandardCrawler(crawler.NewStaticCollector(), crawler.NewInMemoryRegistry(), 2,
+		crawler.WithLinkIndex(links), crawler.WithSitemap(sm))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go c.Start(ctx, ts.URL+"/")
+
+	var resources []crawler.Resource
+	from := make(map[string]string)
+	for res := range c.Results() {
+		resources = append(resources, res)
+		from[res.URL] = res.FromSource
+	}
+
+	if len(resources) != 4 {
+		t.Fatalf("Expected /, /a, /b and /orphan, got %v", from)
+	}
+	if from[ts.URL+"/orphan"] != crawler.SourceSitemap {
+		t.Errorf("Expected /orphan to come from the sitemap, got %q", from[ts.URL+"/orphan"])
+	}
+
+	report := crawler.NewReport(crawler.CrawlInfo{StartURL: ts.URL + "/"}, resources, links)
+	for _, u := range sm.URLs() {
+		report.Sitemap = append(report.Sitemap, u.Loc)
+	}
+	if orphans := report.Orphans(); !slices.Equal(orphans, []string{ts.URL + "/orphan"}) {
+		t.Errorf("Expected /orphan to be the only orphan, got %v", orphans)
+	}
+	missing := report.NotInSitemap()
+	if len(missing) != 1 || missing[0].URL != ts.URL+"/b" {
+		t.Errorf("Expected /b to be missing from the sitemap, got %v", missing)
+	}
+
+	var md bytes.Buffer
+	if err := report.WriteMarkdown(&md); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	if !strings.Contains(md.String(), "## Orphan pages") || !strings.Contains(md.String(), "## Missing from sitemap") {
+		t.Errorf("Expected sitemap sections in the report:\n%s", md.String())
+	}
+}
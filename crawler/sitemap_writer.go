@@ -0,0 +1,100 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MaxSitemapURLs is the most URLs a single sitemap file may list
+const MaxSitemapURLs = 50000
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type xmlURLSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []xmlURL `xml:"url"`
+}
+
+type xmlURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type xmlSitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []xmlSitemap `xml:"sitemap"`
+}
+
+type xmlSitemap struct {
+	Loc string `xml:"loc"`
+}
+
+// SitemapEntries picks the resources that belong in a sitemap: crawled HTML
+// documents that returned 200, are indexable and are their own canonical
+// URL. LastMod is taken from the Last-Modified header when present.
+func SitemapEntries(resources []Resource) []SitemapURL {
+	var out []SitemapURL
+	seen := make(map[string]bool)
+	for _, res := range resources {
+		if res.Kind != "document" || res.Status != "200" || res.External || res.NoIndex() {
+			continue
+		}
+		if res.Canonical != "" && !sameURL(res.Canonical, res.URL) {
+			continue
+		}
+		if seen[res.URL] {
+			continue
+		}
+		seen[res.URL] = true
+
+		entry := SitemapURL{Loc: res.URL}
+		if t, err := http.ParseTime(res.LastModified); err == nil {
+			entry.LastMod = t.UTC().Format(time.RFC3339)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// sameURL reports whether a and b are spellings of the same URL, differing
+// only in ways a Normalizer with default options removes
+func sameURL(a, b string) bool {
+	n, _ := NewNormalizer(NormalizeOptions{})
+	return n.Normalize(a) == n.Normalize(b)
+}
+
+// WriteSitemap writes entries as a sitemap <urlset>. Callers must split
+// lists longer than MaxSitemapURLs across several files.
+func WriteSitemap(w io.Writer, entries []SitemapURL) error {
+	doc := xmlURLSet{Xmlns: sitemapNamespace}
+	for _, e := range entries {
+		doc.URLs = append(doc.URLs, xmlURL{Loc: e.Loc, LastMod: e.LastMod})
+	}
+	return writeXML(w, doc)
+}
+
+// WriteSitemapIndex writes a <sitemapindex> pointing at each sitemap in locs
+func WriteSitemapIndex(w io.Writer, locs []string) error {
+	doc := xmlSitemapIndex{Xmlns: sitemapNamespace}
+	for _, loc := range locs {
+		doc.Sitemaps = append(doc.Sitemaps, xmlSitemap{Loc: loc})
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,80 @@
+package crawler_test
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestSitemapEntries(t *testing.T) {
+	resources := []crawler.Resource{
+		{URL: "https://example.com/", Status: "200", Kind: "document", LastModified: "Tue, 02 Jan 2024 15:04:05 GMT"},
+		{URL: "https://example.com/about", Status: "200", Kind: "document", Canonical: "https://example.com/about"},
+		{URL: "https://example.com/about?print=1", Status: "200", Kind: "document", Canonical: "https://example.com/about"},
+		{URL: "https://example.com/private", Status: "200", Kind: "document", Robots: "noindex"},
+		{URL: "https://example.com/gone", Status: "404", Kind: "document"},
+		{URL: "https://example.com/old", Status: "301", Kind: crawler.KindRedirect},
+		{URL: "https://example.com/logo.png", Status: "200", Kind: "png"},
+		{URL: "https://other.com/", Status: "200", Kind: "document", External: true},
+		{URL: "https://example.com/", Status: "200", Kind: "document"},
+		{URL: "https://example.com/search?a=1&b=2", Status: "200", Kind: "document", Canonical: "HTTPS://Example.com/search?b=2&a=1"},
+	}
+
+	want := []crawler.SitemapURL{
+		{Loc: "https://example.com/", LastMod: "2024-01-02T15:04:05Z"},
+		{Loc: "https://example.com/about"},
+		{Loc: "https://example.com/search?a=1&b=2"},
+	}
+	if got := crawler.SitemapEntries(resources); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestWriteSitemap(t *testing.T) {
+	entries := []crawler.SitemapURL{
+		{Loc: "https://example.com/?a=1&b=2", LastMod: "2024-01-02T15:04:05Z"},
+		{Loc: "https://example.com/about"},
+	}
+
+	var buf bytes.Buffer
+	if err := crawler.WriteSitemap(&buf, entries); err != nil {
+		t.Fatalf("WriteSitemap failed: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8"?>`) {
+		t.Errorf("Expected an XML declaration, got:\n%s", out)
+	}
+	if !strings.Contains(out, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`) {
+		t.Errorf("Expected the sitemap namespace, got:\n%s", out)
+	}
+	if !strings.Contains(out, "a=1&amp;b=2") {
+		t.Errorf("Expected ampersands to be escaped, got:\n%s", out)
+	}
+
+	parsed, _, err := crawler.ParseSitemap(&buf)
+	if err != nil {
+		t.Fatalf("ParseSitemap failed: %v", err)
+	}
+	if len(parsed) != 2 || parsed[0].Loc != entries[0].Loc || parsed[0].LastMod != entries[0].LastMod {
+		t.Errorf("Expected written sitemap to parse back, got %v", parsed)
+	}
+}
+
+func TestWriteSitemapIndex(t *testing.T) {
+	var buf bytes.Buffer
+	locs := []string{"https://example.com/sitemap-1.xml", "https://example.com/sitemap-2.xml"}
+	if err := crawler.WriteSitemapIndex(&buf, locs); err != nil {
+		t.Fatalf("WriteSitemapIndex failed: %v", err)
+	}
+
+	_, children, err := crawler.ParseSitemap(&buf)
+	if err != nil {
+		t.Fatalf("ParseSitemap failed: %v", err)
+	}
+	if !slices.Equal(children, locs) {
+		t.Errorf("Expected %v, got %v", locs, children)
+	}
+}
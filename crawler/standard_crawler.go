@@ -2,61 +2,228 @@ package crawler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/url"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// job is a single queued URL along with its distance from the start URL
+// and the first page found linking to it
+type job struct {
+	url      string
+	original string // The URL as first linked, before normalization
+	depth    int
+	from     string
+	external bool // Out of scope; checked once but never crawled
+}
+
+// Option configures optional behavior on a StandardCrawler
+type Option func(*StandardCrawler)
+
+// WithMaxDepth stops following links more than depth clicks from the start URL.
+// A value of 0 means unlimited.
+func WithMaxDepth(depth int) Option {
+	return func(c *StandardCrawler) {
+		c.maxDepth = depth
+	}
+}
+
+// WithMaxPages ends the crawl once pages URLs have been crawled, leaving the
+// rest of the queue uncrawled. A value of 0 means unlimited.
+func WithMaxPages(pages int) Option {
+	return func(c *StandardCrawler) {
+		c.maxPages = pages
+	}
+}
+
+// WithMaxDuration ends the crawl after d has elapsed. A value of 0 means unlimited.
+func WithMaxDuration(d time.Duration) Option {
+	return func(c *StandardCrawler) {
+		c.maxDuration = d
+	}
+}
+
+// WithLinkIndex records inbound links into idx instead of a private index,
+// so callers can look up every referrer of a URL while the crawl runs. A nil
+// idx keeps no index at all, for crawls too large to hold every link in
+// memory.
+func WithLinkIndex(idx *LinkIndex) Option {
+	return func(c *StandardCrawler) {
+		c.links = idx
+		c.linksSet = true
+	}
+}
+
+// WithUserAgent sets the User-Agent header collectors send. The default is
+// DefaultUserAgent.
+func WithUserAgent(userAgent string) Option {
+	return func(c *StandardCrawler) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithScope replaces the default same-host scope used to decide which links
+// are followed.
+func WithScope(scope Scope) Option {
+	return func(c *StandardCrawler) {
+		c.scope = scope
+	}
+}
+
+// WithRobots makes the crawler honor robots.txt Allow, Disallow and
+// Crawl-delay rules. Disallowed URLs are reported with StatusBlockedByRobots
+// instead of being fetched.
+func WithRobots(robots *Robots) Option {
+	return func(c *StandardCrawler) {
+		c.robots = robots
+	}
+}
+
+// WithExternalLinks checks links that fall outside the crawl scope with
+// checker, typically a LinkChecker. Each external URL is checked once and
+// reported with External set, but its contents are never followed.
+func WithExternalLinks(checker Collector) Option {
+	return func(c *StandardCrawler) {
+		c.external = checker
+	}
+}
+
+// WithMaxRedirects flags redirect chains with more than n hops as
+// RedirectTooLong. The default is DefaultMaxRedirects; 0 disables the check.
+func WithMaxRedirects(n int) Option {
+	return func(c *StandardCrawler) {
+		c.maxRedirects = n
+	}
+}
+
+// WithNormalizer canonicalizes every URL with n before scope checks and
+// registry lookups, so different spellings of a URL are crawled once
+func WithNormalizer(n *Normalizer) Option {
+	return func(c *StandardCrawler) {
+		c.normalizer = n
+	}
+}
+
+// WithSitemap seeds the crawl with every in-scope URL listed in the site's
+// sitemaps. The URLs read are kept on sm for comparing against the results.
+func WithSitemap(sm *Sitemap) Option {
+	return func(c *StandardCrawler) {
+		c.sitemap = sm
+	}
+}
+
+// WithJournal records the crawl's progress in journal. If the journal holds
+// an earlier crawl of the same start URL, Start reports its resources again
+// and continues from its queue instead of starting over.
+func WithJournal(journal *Journal) Option {
+	return func(c *StandardCrawler) {
+		c.journal = journal
+	}
+}
+
+// WithFrontier queues URLs in f instead of a default breadth-first Frontier
+func WithFrontier(f *Frontier) Option {
+	return func(c *StandardCrawler) {
+		c.frontier = f
+	}
+}
+
 // StandardCrawler is the default implementation of the Crawler interface
 type StandardCrawler struct {
-	collector   Collector
-	registry    Registry
-	concurrency int
-	results     chan Resource
-	jobs        chan string
-	active      sync.WaitGroup
-	ctx         context.Context
-	cancel      context.CancelFunc
-	baseURL     *url.URL
+	collector    Collector
+	registry     Registry
+	concurrency  int
+	maxDepth     int
+	maxPages     int
+	maxDuration  time.Duration
+	maxRedirects int
+	started      atomic.Int64 // In-scope jobs started, for WithMaxPages
+	links        *LinkIndex
+	linksSet     bool
+	userAgent    string
+	scope        Scope
+	normalizer   *Normalizer
+	sitemap      *Sitemap
+	journal      *Journal
+	robots       *Robots
+	external     Collector
+	events       chan Event
+	results      chan Resource
+	eventsRead   atomic.Bool // Set once Events is called
+	resultsRead  atomic.Bool // Set once Results is called
+	startedAt    time.Time
+	inFlight     atomic.Int64
+	crawled      atomic.Int64
+	errors       atomic.Int64
+	retries      atomic.Int64
+	skipped      atomic.Int64
+	frontier     *Frontier
+	active       sync.WaitGroup
+	pauseMu      sync.Mutex
+	resume       chan struct{} // Closed on Resume; nil while running
+	ctx          context.Context
+	cancel       context.CancelFunc
+	stopped      context.Context // Cancelled by Stop
+	stop         context.CancelFunc
+	baseURL      *url.URL
 }
 
 // NewStandardCrawler creates a new crawler instance
-func NewStandardCrawler(collector Collector, registry Registry, concurrency int) *StandardCrawler {
-	// Initialize with background context, will be replaced in Start
-	ctx, cancel := context.WithCancel(context.Background())
-	return &StandardCrawler{
-		collector:   collector,
-		registry:    registry,
-		concurrency: concurrency,
-		results:     make(chan Resource, 100),
-		jobs:        make(chan string, 10000),
-		ctx:         ctx,
-		cancel:      cancel,
+func NewStandardCrawler(collector Collector, registry Registry, concurrency int, opts ...Option) *StandardCrawler {
+	// Stop cancels stopped, which Start ties its own context to. It is set up
+	// here so a Stop racing with Start is neither missed nor a data race.
+	stopped, stop := context.WithCancel(context.Background())
+	c := &StandardCrawler{
+		collector:    collector,
+		registry:     registry,
+		concurrency:  concurrency,
+		maxRedirects: DefaultMaxRedirects,
+		events:       make(chan Event, 100),
+		results:      make(chan Resource, 100),
+		ctx:          stopped,
+		cancel:       stop,
+		stopped:      stopped,
+		stop:         stop,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if !c.linksSet {
+		c.links = NewLinkIndex()
+	}
+	if c.frontier == nil {
+		c.frontier, _ = NewFrontier(FrontierOptions{})
+	}
+	// Jobs lost from the frontier will never finish
+	c.frontier.dropped = func(n int) { c.active.Add(-n) }
+	return c
 }
 
 // Start begins the crawling process
 func (c *StandardCrawler) Start(ctx context.Context, startURL string) error {
+	original := startURL
+	startURL = c.normalize(startURL)
 	u, err := url.Parse(startURL)
 	if err != nil {
 		return err
 	}
 	c.baseURL = u
+	c.startedAt = time.Now()
+	if c.scope == nil {
+		c.scope = NewHostScope(u)
+	}
 
 	// Use the provided context for cancellation
-	c.ctx, c.cancel = context.WithCancel(ctx)
-
-	// Add start URL to jobs
-	if c.registry.Visit(startURL) {
-		c.active.Add(1)
-		c.jobs <- startURL
+	if c.maxDuration > 0 {
+		c.ctx, c.cancel = context.WithTimeout(ctx, c.maxDuration)
 	} else {
-		// Should not happen if registry is fresh, but if reused...
-		// If start URL is already visited, we might want to process it anyway?
-		// Or just return?
-		// For now, assume fresh registry.
-		c.active.Add(1)
-		c.jobs <- startURL
+		c.ctx, c.cancel = context.WithCancel(ctx)
 	}
+	defer context.AfterFunc(c.stopped, c.cancel)()
 
 	// Start workers
 	var wg sync.WaitGroup
@@ -65,6 +232,33 @@ func (c *StandardCrawler) Start(ctx context.Context, startURL string) error {
 		go c.worker(&wg)
 	}
 
+	if c.journal != nil && c.journal.Resumed() {
+		if c.journal.StartURL() != startURL {
+			err := fmt.Errorf("journal is for a crawl of %s, not %s", c.journal.StartURL(), startURL)
+			c.cancel()
+			wg.Wait()
+			c.frontier.close()
+			c.finish(err)
+			return err
+		}
+		c.restore()
+	} else {
+		// Add start URL to jobs. If the registry has already seen it we
+		// still process it, since the caller explicitly asked for it.
+		start := job{url: startURL, original: original, depth: 0}
+		c.record("", journalEntry{Kind: entryStart, URL: startURL})
+		c.visit("", startURL)
+		c.record("", queueEntry(start))
+		c.enqueue(start)
+	}
+
+	// Seed from the sitemap while the workers get going on the start URL
+	if c.sitemap != nil {
+		wg.Add(1)
+		c.active.Add(1)
+		go c.seedFromSitemap(u, &wg)
+	}
+
 	// Wait for completion
 	done := make(chan struct{})
 	go func() {
@@ -74,81 +268,479 @@ func (c *StandardCrawler) Start(ctx context.Context, startURL string) error {
 
 	select {
 	case <-c.ctx.Done():
-		// Context cancelled
+		// Context cancelled or max duration reached
 	case <-done:
 		// All jobs finished
 	}
 
 	c.cancel() // Stop workers
 	wg.Wait()
-	close(c.results)
+	c.frontier.close()
+	c.finish(nil)
 
 	return nil
 }
 
-// Stop halts the crawling process
+// Stop halts the crawling process. It may be called before or while Start runs.
 func (c *StandardCrawler) Stop() {
-	c.cancel()
+	c.stop()
+}
+
+// Pause stops workers from starting new jobs. Requests already in flight
+// finish and are reported, and queued URLs stay queued until Resume. Time
+// spent paused counts towards WithMaxDuration.
+func (c *StandardCrawler) Pause() {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	if c.resume == nil {
+		c.resume = make(chan struct{})
+	}
+}
+
+// Resume lets a paused crawl continue
+func (c *StandardCrawler) Resume() {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	if c.resume != nil {
+		close(c.resume)
+		c.resume = nil
+	}
+}
+
+// waitWhilePaused blocks until the crawl is resumed. It returns false if the
+// crawl was stopped instead.
+func (c *StandardCrawler) waitWhilePaused() bool {
+	c.pauseMu.Lock()
+	resume := c.resume
+	c.pauseMu.Unlock()
+	if resume == nil {
+		return true
+	}
+	select {
+	case <-resume:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
 }
 
-// Results returns the channel of discovered resources
+// Events returns the channel of crawl events, closed after EventFinished.
+// Call it before Start: until then events are only buffered, and dropped
+// once the buffer is full. After that the crawl waits for each event to be
+// received, so drain the channel until it is closed.
+func (c *StandardCrawler) Events() <-chan Event {
+	c.eventsRead.Store(true)
+	return c.events
+}
+
+// Results returns the channel of discovered resources, the ones carried by
+// events, closed when the crawl ends. It is a separate stream from Events and
+// is buffered the same way. If both are used, read them concurrently: the
+// crawl waits on whichever one falls behind.
 func (c *StandardCrawler) Results() <-chan Resource {
+	c.resultsRead.Store(true)
 	return c.results
 }
 
+// Links returns the inbound-link index built during the crawl, or nil if
+// WithLinkIndex(nil) turned it off
+func (c *StandardCrawler) Links() *LinkIndex {
+	return c.links
+}
+
 func (c *StandardCrawler) worker(wg *sync.WaitGroup) {
 	defer wg.Done()
 	for {
-		select {
-		case <-c.ctx.Done():
+		if !c.waitWhilePaused() {
 			return
-		case u, ok := <-c.jobs:
-			if !ok {
-				return
-			}
+		}
+		j, ok := c.frontier.pop(c.ctx)
+		if !ok {
+			return
+		}
+		// A worker that was already waiting for a job when the crawl was
+		// paused holds on to it until the crawl resumes, and none are
+		// started once it has been stopped
+		if !c.waitWhilePaused() || c.ctx.Err() != nil {
+			return
+		}
+		// Jobs past the page budget are dropped, but stay queued in the
+		// journal for a resumed crawl with a larger budget
+		if !j.external && !c.reserve() {
+			c.skipped.Add(1)
+			c.emit(Event{Kind: EventSkipped, URL: j.url, Reason: SkipBudget})
+			c.active.Done()
+			continue
+		}
+		c.inFlight.Add(1)
+		c.emit(Event{Kind: EventStarted, URL: j.url})
+		c.process(j)
+		c.inFlight.Add(-1)
+		// A job cut short by Stop is left unfinished in the journal
+		if c.ctx.Err() == nil {
+			c.record("", journalEntry{Kind: entryDone, URL: j.url})
+		}
+		c.active.Done()
+	}
+}
 
-			// Process the URL
-			res, err := c.collector.Collect(c.ctx, u)
-			if err != nil {
-				// If resource is partial (e.g. error status), send it
-				if res != nil {
-					c.sendResult(*res)
-				}
-				c.active.Done()
-				continue
-			}
+// process fetches a single job, reports it and queues its links
+func (c *StandardCrawler) process(j job) {
+	if !c.allowedByRobots(j) {
+		c.report(j, Resource{
+			URL:         j.url,
+			OriginalURL: j.originalURL(),
+			Status:      StatusBlockedByRobots,
+			Kind:        "N/A",
+			FromSource:  j.from,
+			Depth:       j.depth,
+			External:    j.external,
+		})
+		return
+	}
 
-			// Send successful result
-			c.sendResult(*res)
+	collector := c.collector
+	if j.external {
+		collector = c.external
+	}
 
-			// Process links
-			for _, link := range res.Links {
-				// Enforce same-domain policy
-				parsedLink, err := url.Parse(link)
-				if err != nil {
-					continue
-				}
+	// Process the URL
+	ctx := withRetryHook(withUserAgent(c.ctx, c.userAgent), func(attempt int, err error) {
+		c.retries.Add(1)
+		c.emit(Event{Kind: EventRetried, URL: j.url, Attempt: attempt, Err: err})
+	})
+	res, err := collector.Collect(ctx, j.url)
+	if res != nil {
+		res.OriginalURL = j.originalURL()
+		res.Depth = j.depth
+		res.FromSource = j.from
+		res.External = j.external
+	}
+	if err != nil {
+		// If resource is partial (e.g. error status), send it
+		if res != nil {
+			res.Error = err.Error()
+			c.report(j, *res)
+		} else {
+			c.crawled.Add(1)
+			c.errors.Add(1)
+			c.emit(Event{Kind: EventError, URL: j.url, Err: err})
+		}
+		return
+	}
+
+	// A redirect is reported on its own row, and the page it led to is
+	// reported under its final URL unless that URL has been seen already
+	if len(res.Redirects) > 0 {
+		if !c.resolveRedirect(j, res) {
+			return
+		}
+	}
+
+	// Links and the canonical URL are reported, recorded and followed in
+	// the same normalized form as res.URL, remembering how each link was
+	// written so the original can be reported on the page it leads to
+	originals := res.Links
+	if c.normalizer != nil {
+		res.Links = make([]string, len(originals))
+		for i, link := range originals {
+			res.Links[i] = c.normalize(link)
+		}
+	}
+	res.Canonical = c.normalize(res.Canonical)
+
+	// Send successful result
+	c.report(j, *res)
+
+	// External links are checked, never crawled
+	if res.External {
+		return
+	}
+	c.addLinks(j, res.URL, res.Links)
+
+	// Don't follow links from nofollow pages, or past the maximum depth.
+	// External links on pages at the maximum depth are still checked.
+	if c.robots != nil && res.NoFollow() {
+		c.skipped.Add(1)
+		c.emit(Event{Kind: EventSkipped, URL: res.URL, Reason: SkipNoFollow})
+		return
+	}
+	follow := c.maxDepth == 0 || j.depth < c.maxDepth
+	if !follow && len(res.Links) > 0 {
+		c.skipped.Add(1)
+		c.emit(Event{Kind: EventSkipped, URL: res.URL, Reason: SkipDepth})
+	}
+
+	// Process links
+	for i, link := range res.Links {
+		parsedLink, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
 
-				if parsedLink.Host == c.baseURL.Host {
-					if c.registry.Visit(link) {
-						c.active.Add(1)
-						select {
-						case c.jobs <- link:
-						case <-c.ctx.Done():
-							c.active.Done()
-							return
-						}
-					}
+		// Enforce crawl scope
+		if c.scope.InScope(parsedLink) {
+			if follow && c.visit(j.url, link) {
+				next := job{url: link, original: originals[i], depth: j.depth + 1, from: res.URL}
+				c.record(j.url, queueEntry(next))
+				if !c.enqueue(next) {
+					return
 				}
 			}
-			c.active.Done()
+		} else if c.external != nil && (parsedLink.Scheme == "http" || parsedLink.Scheme == "https") {
+			if c.visit(j.url, link) {
+				next := job{url: link, original: originals[i], depth: j.depth + 1, from: res.URL, external: true}
+				c.record(j.url, queueEntry(next))
+				if !c.enqueue(next) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// seedFromSitemap loads the sitemaps for base and queues every in-scope URL
+// they list that hasn't been seen yet
+func (c *StandardCrawler) seedFromSitemap(base *url.URL, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer c.active.Done()
+
+	entries, err := c.sitemap.Load(c.ctx, base)
+	originals := make([]string, len(entries))
+	for i := range entries {
+		originals[i] = entries[i].Loc
+		entries[i].Loc = c.normalize(entries[i].Loc)
+	}
+	c.sitemap.record(entries, err)
+
+	for i, e := range entries {
+		u, err := url.Parse(e.Loc)
+		if err != nil || !c.scope.InScope(u) || !c.visit("", e.Loc) {
+			continue
+		}
+		next := job{url: e.Loc, original: originals[i], depth: 0, from: SourceSitemap}
+		c.record("", queueEntry(next))
+		if !c.enqueue(next) {
+			return
+		}
+	}
+}
+
+// resolveRedirect sends the redirect row for j and rewrites res to describe
+// the page at the end of the chain. It returns false if that page should not
+// be reported: the chain didn't end on a page, the final URL was already
+// visited, or it is out of scope and external links aren't being checked.
+func (c *StandardCrawler) resolveRedirect(j job, res *Resource) bool {
+	c.report(j, Resource{
+		URL:            j.url,
+		OriginalURL:    j.originalURL(),
+		Status:         res.Redirects[0].Status,
+		Kind:           KindRedirect,
+		FromSource:     j.from,
+		Depth:          j.depth,
+		External:       j.external,
+		Redirects:      res.Redirects,
+		FinalURL:       res.FinalURL,
+		RedirectIssues: redirectIssues(res.Redirects, c.maxRedirects),
+	})
+	finalURL := c.normalize(res.FinalURL)
+	if finalURL != "" {
+		c.addLinks(j, j.url, []string{finalURL})
+	}
+
+	if code, err := strconv.Atoi(res.Status); err != nil || isRedirectStatus(code) {
+		return false
+	}
+	final, err := url.Parse(finalURL)
+	if err != nil {
+		return false
+	}
+	if !j.external && !c.scope.InScope(final) {
+		if c.external == nil {
+			return false
+		}
+		res.External = true
+	}
+	if !c.visit(j.url, finalURL) {
+		return false
+	}
+
+	res.URL = finalURL
+	res.OriginalURL = ""
+	if finalURL != res.FinalURL {
+		res.OriginalURL = res.FinalURL
+	}
+	res.FromSource = j.url
+	res.Redirects = nil
+	res.FinalURL = ""
+	return true
+}
+
+// normalize canonicalizes raw with the configured Normalizer, if any
+func (c *StandardCrawler) normalize(raw string) string {
+	if c.normalizer == nil || raw == "" {
+		return raw
+	}
+	return c.normalizer.Normalize(raw)
+}
+
+// originalURL returns the URL as linked, or "" if normalization left it unchanged
+func (j job) originalURL() string {
+	if j.original == j.url {
+		return ""
+	}
+	return j.original
+}
+
+// enqueue adds j to the frontier. It returns false if the crawl has been
+// cancelled.
+func (c *StandardCrawler) enqueue(j job) bool {
+	if c.ctx.Err() != nil {
+		return false
+	}
+	c.active.Add(1)
+	c.frontier.push(j)
+	c.emit(Event{Kind: EventQueued, URL: j.url})
+	return true
+}
+
+// allowedByRobots reports whether robots.txt permits fetching j, waiting out
+// any Crawl-delay for its host first. It always returns true without WithRobots.
+func (c *StandardCrawler) allowedByRobots(j job) bool {
+	if c.robots == nil {
+		return true
+	}
+	u, err := url.Parse(j.url)
+	if err != nil {
+		return true
+	}
+	if !c.robots.Allowed(c.ctx, u) {
+		return false
+	}
+	_ = c.robots.Wait(c.ctx, u)
+	return true
+}
+
+// reserve claims a slot in the page budget. It returns false once the budget
+// set by WithMaxPages has been used up.
+func (c *StandardCrawler) reserve() bool {
+	n := c.started.Add(1)
+	if c.maxPages > 0 && n > int64(c.maxPages) {
+		c.started.Add(-1)
+		return false
+	}
+	return true
+}
+
+// restore reports the resources from a resumed journal and queues the jobs
+// it left unfinished
+func (c *StandardCrawler) restore() {
+	for _, u := range c.journal.visited {
+		c.registry.Visit(u)
+	}
+	for _, e := range c.journal.links {
+		c.links.AddLinks(e.URL, e.Links)
+	}
+	c.started.Store(c.journal.crawled)
+	for _, res := range c.journal.resources {
+		c.sendResource(res)
+	}
+	for _, j := range c.journal.pending {
+		if !c.enqueue(j) {
+			return
 		}
 	}
 }
 
-func (c *StandardCrawler) sendResult(res Resource) {
+// record writes e to the journal, if any, on behalf of the job with URL jobURL
+func (c *StandardCrawler) record(jobURL string, e journalEntry) {
+	if c.journal != nil {
+		c.journal.write(jobURL, e)
+	}
+}
+
+// visit marks u visited in the registry, journaling it for the job with URL
+// jobURL. It returns false if u had already been visited.
+func (c *StandardCrawler) visit(jobURL, u string) bool {
+	if !c.registry.Visit(u) {
+		return false
+	}
+	c.record(jobURL, journalEntry{Kind: entryVisit, URL: u})
+	return true
+}
+
+// addLinks records links from a page in the link index and journal
+func (c *StandardCrawler) addLinks(j job, from string, links []string) {
+	c.links.AddLinks(from, links)
+	c.record(j.url, journalEntry{Kind: entryLinks, URL: from, Links: links})
+}
+
+// report journals and sends a result for j
+func (c *StandardCrawler) report(j job, res Resource) {
+	c.record(j.url, journalEntry{Kind: entryResult, Resource: &res})
+	c.sendResource(res)
+}
+
+// sendResource sends the event reporting res
+func (c *StandardCrawler) sendResource(res Resource) {
+	e := Event{Kind: EventCompleted, URL: res.URL, Resource: &res}
+	switch {
+	case res.Status == StatusBlockedByRobots:
+		e.Kind, e.Reason = EventSkipped, SkipRobots
+		c.skipped.Add(1)
+	case res.Error != "":
+		e.Kind, e.Err = EventError, errors.New(res.Error)
+		c.crawled.Add(1)
+		c.errors.Add(1)
+	default:
+		c.crawled.Add(1)
+	}
+	c.emit(e)
+}
+
+// emit sends e with the current stats to Events, and its resource to
+// Results, unless the crawl has been stopped
+func (c *StandardCrawler) emit(e Event) {
+	e.Stats = c.stats()
+	send(c.ctx.Done(), c.events, &c.eventsRead, e)
+	if e.Resource != nil {
+		send(c.ctx.Done(), c.results, &c.resultsRead, *e.Resource)
+	}
+}
+
+// finish sends EventFinished and closes both streams. The crawl is already
+// stopped, so the event is only waited on if Events is being read.
+func (c *StandardCrawler) finish(err error) {
+	send(nil, c.events, &c.eventsRead, Event{Kind: EventFinished, Err: err, Stats: c.stats()})
+	close(c.events)
+	close(c.results)
+}
+
+// send delivers v on ch. While nobody has asked for ch it only fills the
+// buffer, so an unread stream never holds up the crawl; after that it waits
+// for a receiver or for done.
+func send[T any](done <-chan struct{}, ch chan T, read *atomic.Bool, v T) {
+	if !read.Load() {
+		select {
+		case ch <- v:
+		default:
+		}
+		return
+	}
 	select {
-	case c.results <- res:
-	case <-c.ctx.Done():
+	case ch <- v:
+	case <-done:
+	}
+}
+
+func (c *StandardCrawler) stats() Stats {
+	return Stats{
+		Queued:   c.frontier.Len(),
+		InFlight: int(c.inFlight.Load()),
+		Crawled:  int(c.crawled.Load()),
+		Errors:   int(c.errors.Load()),
+		Retries:  int(c.retries.Load()),
+		Skipped:  int(c.skipped.Load()),
+		Elapsed:  time.Since(c.startedAt),
 	}
 }
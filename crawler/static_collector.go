@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -20,68 +21,101 @@ type StaticCollector struct {
 // NewStaticCollector creates a new StaticCollector with a default HTTP client
 func NewStaticCollector() *StaticCollector {
 	return &StaticCollector{
-		client: &http.Client{
+		client: noFollowClient(&http.Client{
 			Timeout: 10 * time.Second,
-		},
+		}),
 	}
 }
 
-// Collect fetches the targetURL and extracts resources
+// Collect fetches the targetURL and extracts resources. Redirects are
+// followed, with each hop recorded in Redirects and the page that was
+// finally fetched in FinalURL.
 func (c *StaticCollector) Collect(ctx context.Context, targetURL string) (*Resource, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.client.Do(req)
+	trace := newTimingTrace()
+	resp, hops, err := followRedirects(trace.withContext(ctx), c.client, "GET", targetURL)
 	if err != nil {
 		// Return resource with Error status to indicate failure but preserve URL
-		return &Resource{URL: targetURL, Status: "Error", Kind: "N/A"}, err
+		return &Resource{URL: targetURL, Status: "Error", Kind: "N/A", Redirects: hops, Timing: trace.done()}, err
 	}
 	defer resp.Body.Close()
+	finalURL := resp.Request.URL.String()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
+	timing := trace.done()
 	if err != nil {
-		return &Resource{URL: targetURL, Status: "Read Err", Kind: "N/A"}, err
+		return &Resource{URL: targetURL, Status: "Read Err", Kind: "N/A", Redirects: hops, Timing: timing}, err
 	}
 
 	contentType := resp.Header.Get("Content-Type")
 	kind := DetermineKind(contentType)
 
 	var links []string
+	var robots []string
+	var meta pageMeta
 	if kind == "document" {
-		links = extractLinks(strings.NewReader(string(bodyBytes)), targetURL)
+		links, meta = extractLinks(strings.NewReader(string(bodyBytes)), finalURL)
+		if meta.robots != "" {
+			robots = append(robots, meta.robots)
+		}
 	}
+	robots = append(robots, robotsTagDirectives(resp.Header.Values("X-Robots-Tag"), userAgentFrom(ctx))...)
 
-	return &Resource{
+	res := &Resource{
 		URL:        targetURL,
 		Status:     fmt.Sprintf("%d", resp.StatusCode),
 		Kind:       kind,
 		Size:       int64(len(bodyBytes)),
 		Links:      links,
 		FromSource: "", // Caller manages source attribution
-	}, nil
+		Robots:     strings.Join(robots, ", "),
+		Redirects:  hops,
+
+		Canonical:    meta.canonical,
+		LastModified: resp.Header.Get("Last-Modified"),
+
+		Headers:         resp.Header.Clone(),
+		ContentType:     contentType,
+		ContentEncoding: contentEncoding(resp),
+		Timing:          timing,
+	}
+	if len(hops) > 0 {
+		res.FinalURL = finalURL
+	}
+	return res, nil
 }
 
+// pageMeta holds the page-level directives found in a document's markup
+type pageMeta struct {
+	robots    string // Content of <meta name="robots">
+	canonical string // Resolved href of <link rel="canonical">
+}
 
-func extractLinks(body io.Reader, currentUrl string) []string {
+// extractLinks returns the resolved links in body along with its robots and
+// canonical directives
+func extractLinks(body io.Reader, currentUrl string) ([]string, pageMeta) {
 	var links []string
+	var meta pageMeta
 	z := html.NewTokenizer(body)
 
 	baseUrl, err := url.Parse(currentUrl)
 	if err != nil {
-		return links
+		return links, meta
 	}
 
 	for {
 		tt := z.Next()
 		switch tt {
 		case html.ErrorToken:
-			return links
+			return links, meta
 		case html.StartTagToken, html.SelfClosingTagToken:
 			t := z.Token()
 			var attrKey string
 			switch t.Data {
+			case "meta":
+				if attr(t, "name") == "robots" {
+					meta.robots = attr(t, "content")
+				}
+				continue
 			case "a", "link":
 				attrKey = "href"
 			case "img", "script", "video", "audio", "source":
@@ -101,8 +135,22 @@ func extractLinks(body io.Reader, currentUrl string) []string {
 					// Filtering should happen in the Crawler/Registry.
 					resolved.Fragment = ""
 					links = append(links, resolved.String())
+					if t.Data == "link" && slices.Contains(strings.Fields(attr(t, "rel")), "canonical") {
+						meta.canonical = resolved.String()
+					}
 				}
 			}
 		}
 	}
 }
+
+// attr returns the value of the named attribute on t, with the name matched
+// case-insensitively and the value lowercased
+func attr(t html.Token, name string) string {
+	for _, a := range t.Attr {
+		if strings.EqualFold(a.Key, name) {
+			return strings.ToLower(strings.TrimSpace(a.Val))
+		}
+	}
+	return ""
+}
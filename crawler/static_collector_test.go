@@ -1,6 +1,7 @@
 package crawler_test
 
 import (
+	"compress/gzip"
 	"context"
 	"net/http"
 	"net/http/httptest"
@@ -43,3 +44,106 @@ func TestStaticCollector_Collect(t *testing.T) {
 		t.Errorf("Expected 2 links, got %d", len(resource.Links))
 	}
 }
+
+func TestStaticCollector_CanonicalAndLastModified(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Last-Modified", "Tue, 02 Jan 2024 15:04:05 GMT")
+		w.Write([]byte(`<html><head><link rel="Canonical" href="/main"></head></html>`))
+	}))
+	defer ts.Close()
+
+	resource, err := crawler.NewStaticCollector().Collect(context.Background(), ts.URL+"/page?ref=x")
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if resource.Canonical != ts.URL+"/main" {
+		t.Errorf("Expected resolved canonical %s/main, got %q", ts.URL, resource.Canonical)
+	}
+	if resource.LastModified != "Tue, 02 Jan 2024 15:04:05 GMT" {
+		t.Errorf("Expected Last-Modified to be kept, got %q", resource.LastModified)
+	}
+}
+
+func TestStaticCollector_HeadersAndTiming(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(`<html></html>`))
+	}))
+	defer ts.Close()
+
+	resource, err := crawler.NewStaticCollector().Collect(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if resource.ContentType != "text/html; charset=utf-8" {
+		t.Errorf("Expected Content-Type to be kept, got %q", resource.ContentType)
+	}
+	if resource.Headers.Get("Cache-Control") != "max-age=60" {
+		t.Errorf("Expected response headers to be kept, got %v", resource.Headers)
+	}
+	if resource.Timing == nil {
+		t.Fatal("Expected timing to be measured")
+	}
+	if resource.Timing.Connect <= 0 || resource.Timing.TTFB <= 0 || resource.Timing.Total < resource.Timing.TTFB {
+		t.Errorf("Expected connect, TTFB and total to be measured, got %+v", *resource.Timing)
+	}
+}
+
+func TestStaticCollector_ContentEncoding(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`<a href="/next">next</a>`))
+		gz.Close()
+	}))
+	defer ts.Close()
+
+	resource, err := crawler.NewStaticCollector().Collect(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if resource.ContentEncoding != "gzip" {
+		t.Errorf("Expected gzip encoding to be reported, got %q", resource.ContentEncoding)
+	}
+	if len(resource.Links) != 1 {
+		t.Errorf("Expected the decoded body to be parsed, got links %v", resource.Links)
+	}
+}
+
+func TestStaticCollector_XRobotsTagAgents(t *testing.T) {
+	tests := []struct {
+		headers      []string
+		wantRobots   string
+		wantNoIndex  bool
+		wantNoFollow bool
+	}{
+		{[]string{"noindex"}, "noindex", true, false},
+		{[]string{"googlebot: noindex"}, "", false, false},
+		{[]string{"googlebot: noindex, nofollow"}, "", false, false},
+		{[]string{"googlebot: noindex", "nofollow"}, "nofollow", false, true},
+		{[]string{"Huntsman: noindex"}, "noindex", true, false},
+		{[]string{"nofollow, googlebot: noindex, huntsman: none"}, "nofollow, none", true, true},
+		{[]string{"unavailable_after: 25 Jun 2010 15:00:00 PST"}, "unavailable_after: 25 Jun 2010 15:00:00 PST", false, false},
+	}
+	for _, tt := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, h := range tt.headers {
+				w.Header().Add("X-Robots-Tag", h)
+			}
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<p>ok</p>"))
+		}))
+		res, err := crawler.NewStaticCollector().Collect(context.Background(), ts.URL)
+		ts.Close()
+		if err != nil {
+			t.Fatalf("Collect failed: %v", err)
+		}
+		if res.Robots != tt.wantRobots || res.NoIndex() != tt.wantNoIndex || res.NoFollow() != tt.wantNoFollow {
+			t.Errorf("X-Robots-Tag %q: got %q (noindex %v, nofollow %v), want %q (%v, %v)",
+				tt.headers, res.Robots, res.NoIndex(), res.NoFollow(), tt.wantRobots, tt.wantNoIndex, tt.wantNoFollow)
+		}
+	}
+}
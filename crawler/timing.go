@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing breaks down how long fetching a resource took. DNS, Connect, TLS
+// and TTFB describe the final request of a redirect chain and are zero when
+// a pooled connection was reused. Total covers the whole chain, including
+// reading the body. Durations are encoded in JSON as nanoseconds.
+type Timing struct {
+	DNS     time.Duration `json:"dns"`
+	Connect time.Duration `json:"connect"`
+	TLS     time.Duration `json:"tls"`
+	TTFB    time.Duration `json:"ttfb"` // From starting the request to the first response byte
+	Total   time.Duration `json:"total"`
+}
+
+// timingTrace records Timing through an httptrace.ClientTrace
+type timingTrace struct {
+	mu                                      sync.Mutex
+	start, request, dns, connect, handshake time.Time
+	timing                                  Timing
+}
+
+func newTimingTrace() *timingTrace {
+	return &timingTrace{start: time.Now()}
+}
+
+// withContext returns ctx with the trace attached to every request made with it
+func (t *timingTrace) withContext(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.record(func() {
+				// A new request in a redirect chain starts a fresh breakdown
+				t.request = time.Now()
+				t.timing = Timing{}
+			})
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func() { t.dns = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func() { t.timing.DNS = time.Since(t.dns) })
+		},
+		ConnectStart: func(string, string) {
+			t.record(func() { t.connect = time.Now() })
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.record(func() { t.timing.Connect = time.Since(t.connect) })
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.record(func() { t.handshake = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() { t.timing.TLS = time.Since(t.handshake) })
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() { t.timing.TTFB = time.Since(t.request) })
+		},
+	})
+}
+
+func (t *timingTrace) record(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f()
+}
+
+// done returns the recorded Timing with Total measured up to now
+func (t *timingTrace) done() *Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timing := t.timing
+	timing.Total = time.Since(t.start)
+	return &timing
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jturmel/huntsman/crawler"
+)
+
+// detailView shows everything known about one resource in place of the
+// results table. Outgoing links and referrers can be selected to move to
+// their own detail view, with a history to go back through.
+type detailView struct {
+	res       crawler.Resource
+	referrers []string
+	links     []string // Navigable URLs: outgoing links, then referrers
+	cursor    int
+	moved     bool // Whether the cursor has been moved, to scroll to it
+	history   []detailView
+}
+
+func newDetailView(res crawler.Resource, referrers []string) *detailView {
+	d := &detailView{res: res, referrers: referrers}
+	d.links = append(append([]string{}, res.Links...), referrers...)
+	return d
+}
+
+// newDetailView builds a detail view for res with its known referrers
+func (m model) newDetailView(res crawler.Resource) *detailView {
+	var refs []string
+	if m.links != nil {
+		refs = m.links.Referrers(res.URL)
+	}
+	return newDetailView(res, refs)
+}
+
+// updateDetail handles keys while the detail view is open
+func (m model) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Copy so that navigation doesn't change views held by earlier models
+	d := *m.detail
+	m.detail = &d
+
+	switch msg.String() {
+	case "ctrl+c", "q":
+		m.shutdown()
+		return m, tea.Quit
+	case "esc", "backspace":
+		if !d.back() {
+			m.detail = nil
+		}
+	case "up", "k":
+		d.move(-1)
+	case "down", "j":
+		d.move(1)
+	case "pgup":
+		d.move(-10)
+	case "pgdown":
+		d.move(10)
+	case "o":
+		openURL(d.selected())
+	case "enter":
+		if len(d.links) == 0 {
+			return m, nil
+		}
+		target := d.selected()
+		for _, res := range m.resources {
+			if res.URL == target {
+				d.push(m.newDetailView(res))
+				return m, nil
+			}
+		}
+		m.message = "Not crawled: " + target
+		return m, tea.Tick(time.Second*5, func(t time.Time) tea.Msg {
+			return clearMsg{}
+		})
+	}
+	return m, nil
+}
+
+// selected returns the URL under the cursor, or the resource's own URL when
+// it has no links
+func (d *detailView) selected() string {
+	if len(d.links) == 0 {
+		return d.res.URL
+	}
+	return d.links[d.cursor]
+}
+
+func (d *detailView) move(delta int) {
+	d.moved = true
+	d.cursor += delta
+	if d.cursor < 0 {
+		d.cursor = 0
+	}
+	if d.cursor >= len(d.links) {
+		d.cursor = len(d.links) - 1
+	}
+	if d.cursor < 0 {
+		d.cursor = 0
+	}
+}
+
+// push shows next, remembering the current view for back
+func (d *detailView) push(next *detailView) {
+	cur := *d
+	cur.history = nil
+	next.history = append(slices.Clip(d.history), cur)
+	*d = *next
+}
+
+// back returns to the previous view. It returns false when there is none.
+func (d *detailView) back() bool {
+	if len(d.history) == 0 {
+		return false
+	}
+	prev := d.history[len(d.history)-1]
+	prev.history = d.history[:len(d.history)-1]
+	*d = prev
+	return true
+}
+
+// render lays the details out in width columns, scrolled so the cursor stays
+// within height lines
+func (d *detailView) render(width, height int, theme Theme) string {
+	label := lipgloss.NewStyle().Bold(true)
+	selected := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(theme.TableSelectedFG)).
+		Background(lipgloss.Color(theme.TableSelectedBG))
+
+	var lines []string
+	cursorLine := 0
+	add := func(s string) {
+		lines = append(lines, wrapLine(s, width)...)
+	}
+	field := func(name, value string) {
+		if value != "" {
+			add(label.Render(name+":") + " " + value)
+		}
+	}
+	section := func(title string) {
+		lines = append(lines, "", label.Render(title))
+	}
+
+	res := d.res
+	field("URL", res.URL)
+	field("Original URL", res.OriginalURL)
+	field("Status", res.Status)
+	field("Type", res.Kind)
+	field("Size", fmt.Sprintf("%d bytes (%s)", res.Size, formatSize(res.Size)))
+	field("Content-Type", res.ContentType)
+	field("Content-Encoding", res.ContentEncoding)
+	field("Depth", fmt.Sprintf("%d", res.Depth))
+	field("From Source", res.FromSource)
+	field("Robots", res.Robots)
+	field("Canonical", res.Canonical)
+	if res.External {
+		field("External", "yes")
+	}
+	if t := res.Timing; t != nil {
+		field("Timing", fmt.Sprintf("DNS %s • connect %s • TLS %s • TTFB %s • total %s", t.DNS, t.Connect, t.TLS, t.TTFB, t.Total))
+	}
+	field("Error", res.Error)
+
+	if len(res.Redirects) > 0 {
+		section("Redirect chain")
+		for _, hop := range res.Redirects {
+			add(fmt.Sprintf("  %s %s → %s", hop.Status, hop.URL, hop.Location))
+		}
+		for _, issue := range res.RedirectIssues {
+			add("  ! " + issue)
+		}
+	}
+
+	if len(res.Headers) > 0 {
+		section("Response headers")
+		names := make([]string, 0, len(res.Headers))
+		for name := range res.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, v := range res.Headers[name] {
+				add(fmt.Sprintf("  %s: %s", name, v))
+			}
+		}
+	}
+
+	linkList := func(title string, urls []string, offset int) {
+		section(fmt.Sprintf("%s (%d)", title, len(urls)))
+		for i, u := range urls {
+			if offset+i == d.cursor {
+				cursorLine = len(lines)
+				lines = append(lines, selected.Render(truncate("> "+u, width)))
+			} else {
+				lines = append(lines, truncate("  "+u, width))
+			}
+		}
+	}
+	linkList("Outgoing links", res.Links, 0)
+	linkList("Linked from", d.referrers, len(res.Links))
+
+	// Scroll so the selected link is visible once the user starts moving
+	// through the links, keeping the top fields in view for as long as possible
+	start := 0
+	if d.moved && cursorLine >= height {
+		start = cursorLine - height + 1
+	}
+	end := start + height
+	if end > len(lines) {
+		end = len(lines)
+	}
+	visible := lines[start:end]
+	for len(visible) < height {
+		visible = append(visible, "")
+	}
+	return lipgloss.NewStyle().Width(width).Render(strings.Join(visible, "\n"))
+}
+
+// wrapLine hard-wraps s so that no line is wider than width, keeping long
+// URLs readable in full
+func wrapLine(s string, width int) []string {
+	if width <= 0 || lipgloss.Width(s) <= width {
+		return []string{s}
+	}
+	var out []string
+	for _, line := range strings.Split(lipgloss.NewStyle().Width(width).Render(s), "\n") {
+		out = append(out, strings.TrimRight(line, " "))
+	}
+	return out
+}
+
+// truncate shortens s to width, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if width <= 1 || len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestNewDetailView(t *testing.T) {
+	d := newDetailView(crawler.Resource{URL: "/a", Links: []string{"/b", "/c"}}, []string{"/"})
+	if want := []string{"/b", "/c", "/"}; !slices.Equal(d.links, want) {
+		t.Errorf("Expected links then referrers %v, got %v", want, d.links)
+	}
+}
+
+func TestDetailView_Move(t *testing.T) {
+	d := newDetailView(crawler.Resource{URL: "/a", Links: []string{"/b", "/c", "/d"}}, nil)
+	steps := []struct {
+		delta int
+		want  int
+	}{
+		{1, 1},
+		{10, 2},
+		{-1, 1},
+		{-10, 0},
+	}
+	for _, step := range steps {
+		d.move(step.delta)
+		if d.cursor != step.want {
+			t.Errorf("move(%d): expected cursor %d, got %d", step.delta, step.want, d.cursor)
+		}
+	}
+	if got := d.selected(); got != "/b" {
+		t.Errorf("Expected /b to be selected, got %q", got)
+	}
+}
+
+func TestDetailView_MoveWithoutLinks(t *testing.T) {
+	d := newDetailView(crawler.Resource{URL: "/a"}, nil)
+	for _, delta := range []int{1, -1, 10, -10} {
+		d.move(delta)
+		if d.cursor != 0 {
+			t.Errorf("move(%d): expected the cursor to stay at 0, got %d", delta, d.cursor)
+		}
+	}
+	if got := d.selected(); got != "/a" {
+		t.Errorf("Expected the resource's own URL, got %q", got)
+	}
+}
+
+func TestDetailView_PushBack(t *testing.T) {
+	d := newDetailView(crawler.Resource{URL: "/", Links: []string{"/a", "/b"}}, nil)
+	d.move(1)
+	d.push(newDetailView(crawler.Resource{URL: "/b", Links: []string{"/c"}}, nil))
+	d.push(newDetailView(crawler.Resource{URL: "/c"}, nil))
+	if d.res.URL != "/c" || len(d.history) != 2 {
+		t.Fatalf("Expected /c with 2 views behind it, got %s with %d", d.res.URL, len(d.history))
+	}
+
+	for _, want := range []string{"/b", "/"} {
+		if !d.back() {
+			t.Fatalf("Expected to go back to %s", want)
+		}
+		if d.res.URL != want {
+			t.Errorf("Expected to go back to %s, got %s", want, d.res.URL)
+		}
+	}
+	if d.cursor != 1 {
+		t.Errorf("Expected the cursor to be restored to 1, got %d", d.cursor)
+	}
+	if d.back() {
+		t.Error("Expected no view before the first")
+	}
+}
+
+func TestDetailView_CopiesDontShareHistory(t *testing.T) {
+	// Going back leaves spare capacity in the history, which a later push
+	// would write into for every copy of the view without slices.Clip
+	d := newDetailView(crawler.Resource{URL: "/", Links: []string{"/a"}}, nil)
+	d.push(newDetailView(crawler.Resource{URL: "/a", Links: []string{"/b", "/c"}}, nil))
+	d.push(newDetailView(crawler.Resource{URL: "/b"}, nil))
+	d.back()
+
+	first, second := *d, *d
+	first.move(1)
+	first.push(newDetailView(crawler.Resource{URL: "/c"}, nil))
+	second.push(newDetailView(crawler.Resource{URL: "/b"}, nil))
+
+	first.back()
+	if first.res.URL != "/a" || first.cursor != 1 {
+		t.Errorf("Expected /a with the cursor at 1, got %s at %d", first.res.URL, first.cursor)
+	}
+	second.back()
+	if second.res.URL != "/a" || second.cursor != 0 {
+		t.Errorf("Expected /a with the cursor at 0, got %s at %d", second.res.URL, second.cursor)
+	}
+}
+
+func TestModel_UpdateDetail(t *testing.T) {
+	m := newTestModel(
+		crawler.Resource{URL: "/", Links: []string{"/a", "/missing"}},
+		crawler.Resource{URL: "/a"},
+	)
+	m.detail = m.newDetailView(m.resources[0])
+
+	// Navigation leaves the view held by the earlier model alone
+	next, _ := m.updateDetail(tea.KeyMsg{Type: tea.KeyDown})
+	moved := next.(model)
+	if m.detail.cursor != 0 || moved.detail.cursor != 1 {
+		t.Errorf("Expected cursors 0 and 1, got %d and %d", m.detail.cursor, moved.detail.cursor)
+	}
+
+	// Links that weren't crawled can't be opened
+	next, _ = moved.updateDetail(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := next.(model); got.detail.res.URL != "/" || got.message == "" {
+		t.Errorf("Expected to stay on / with a message, got %s with %q", got.detail.res.URL, got.message)
+	}
+
+	next, _ = m.updateDetail(tea.KeyMsg{Type: tea.KeyEnter})
+	opened := next.(model)
+	if opened.detail.res.URL != "/a" {
+		t.Fatalf("Expected /a to be opened, got %s", opened.detail.res.URL)
+	}
+
+	// Esc goes back, then closes the view
+	next, _ = opened.updateDetail(tea.KeyMsg{Type: tea.KeyEsc})
+	if got := next.(model); got.detail == nil || got.detail.res.URL != "/" {
+		t.Fatalf("Expected to go back to /, got %+v", got.detail)
+	}
+	next, _ = next.(model).updateDetail(tea.KeyMsg{Type: tea.KeyEsc})
+	if got := next.(model); got.detail != nil {
+		t.Errorf("Expected the detail view to be closed, got %s", got.detail.res.URL)
+	}
+}
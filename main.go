@@ -20,6 +20,7 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"time"
@@ -29,7 +30,6 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/jturmel/huntsman/crawler"
 )
 
 var (
@@ -37,11 +37,9 @@ var (
 			BorderStyle(lipgloss.RoundedBorder())
 	blurredStyle = lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder())
-
-	maxConcurrency = 10
 )
 
-func initialModel() model {
+func initialModel(opts crawlOptions, exportOpts exportOptions) model {
 	theme := LoadTheme()
 
 	focusedStyle = focusedStyle.BorderForeground(lipgloss.Color(theme.FocusedColor))
@@ -53,6 +51,8 @@ func initialModel() model {
 	ti.CharLimit = 156
 	ti.Width = 96
 	ti.Prompt = " "
+	// Offer to continue the crawl saved with --resume
+	ti.SetValue(opts.journalStartURL())
 
 	fi := textinput.New()
 	fi.Placeholder = "Filter results..."
@@ -105,14 +105,34 @@ func initialModel() model {
 		spinner:     sp,
 		table:       t,
 		visited:     make(map[string]bool),
-		results:     make(chan crawler.Resource, 10000),
-		spaMode:     true,
+		events:      make(chan crawlEventMsg, 10000),
+		opts:        opts,
+		exportOpts:  exportOpts,
 		theme:       theme,
 	}
 }
 
 func main() {
-	p := tea.NewProgram(initialModel())
+	if len(os.Args) > 1 && os.Args[1] == "crawl" {
+		os.Exit(runCrawl(os.Args[2:]))
+	}
+
+	opts := defaultCrawlOptions()
+	fs := flag.NewFlagSet("huntsman", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  huntsman [flags]\n  huntsman crawl [flags] <url>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	opts.register(fs)
+	exportOpts := defaultExportOptions()
+	exportOpts.register(fs)
+	fs.Parse(os.Args[1:])
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "huntsman: %v\n", err)
+		os.Exit(2)
+	}
+
+	p := tea.NewProgram(initialModel(opts, exportOpts))
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
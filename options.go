@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/jturmel/huntsman/crawler"
+)
+
+const (
+	modeStatic   = "static"
+	modeHeadless = "headless"
+
+	scopeHost   = "host"
+	scopeDomain = "domain"
+	scopePath   = "path"
+
+	registryMemory = "memory"
+	registryDisk   = "disk"
+	registryBloom  = "bloom"
+
+	priorityBoost   = "boost"
+	prioritySitemap = "sitemap"
+	priorityPages   = "pages"
+	priorityDepth   = "depth"
+
+	// maxConcurrency caps the automatically chosen worker count
+	maxConcurrency = 10
+	// maxHeadlessConcurrency caps workers when each one drives a browser tab
+	maxHeadlessConcurrency = 8
+)
+
+// crawlOptions holds the crawl settings shared by the TUI and the crawl subcommand
+type crawlOptions struct {
+	mode         string
+	concurrency  int
+	maxDepth     int
+	maxPages     int
+	maxDuration  time.Duration
+	scope        string
+	userAgent    string
+	ignoreRobots bool
+	rps          float64
+	burst        int
+	pathPrefix   string
+	allowHosts   stringList
+	include      stringList
+	exclude      stringList
+	external     bool
+	redirectHops int
+	retries      int
+	retryBackoff time.Duration
+
+	noNormalize    bool
+	stripParams    stringList
+	trailingSlash  string
+	lowercasePaths bool
+
+	sitemap     bool
+	sitemapURLs stringList
+
+	resume string
+
+	registry      string
+	registryPath  string
+	bloomCapacity int
+	bloomFPRate   float64
+
+	order          string
+	frontierMemory int
+	spillDir       string
+	priority       stringList
+	boosts         stringList
+}
+
+// stringList is a flag that can be repeated or given a comma-separated list
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func defaultCrawlOptions() crawlOptions {
+	return crawlOptions{
+		mode:      modeHeadless,
+		scope:     scopeHost,
+		userAgent: crawler.DefaultUserAgent,
+		burst:     1,
+
+		retryBackoff: time.Second,
+
+		redirectHops:  crawler.DefaultMaxRedirects,
+		trailingSlash: string(crawler.TrailingSlashKeep),
+
+		registry:      registryMemory,
+		bloomCapacity: 10_000_000,
+		bloomFPRate:   0.001,
+
+		order:          string(crawler.OrderBFS),
+		frontierMemory: crawler.DefaultFrontierMemory,
+	}
+}
+
+// register binds the options to flags on fs
+func (o *crawlOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.mode, "mode", o.mode, "crawl mode: static or headless")
+	fs.IntVar(&o.concurrency, "concurrency", o.concurrency, "number of concurrent workers (0 picks a default from the CPU count)")
+	fs.IntVar(&o.maxDepth, "depth", o.maxDepth, "maximum clicks from the start URL (0 for unlimited)")
+	fs.IntVar(&o.maxPages, "max-pages", o.maxPages, "maximum number of URLs to crawl (0 for unlimited)")
+	fs.DurationVar(&o.maxDuration, "max-duration", o.maxDuration, "stop the crawl after this long, e.g. 10m (0 for unlimited)")
+	fs.StringVar(&o.scope, "scope", o.scope, "which links to follow: host (same host), domain (same registrable domain, including subdomains) or path (same host, under the start URL's directory)")
+	fs.StringVar(&o.pathPrefix, "path-prefix", o.pathPrefix, "only follow links whose path starts with this prefix, e.g. /docs")
+	fs.Var(&o.allowHosts, "allow-host", "extra host to crawl in addition to --scope (repeatable or comma-separated)")
+	fs.Var(&o.include, "include", "only follow URLs matching this glob or /regex/ (repeatable)")
+	fs.Var(&o.exclude, "exclude", "never follow URLs matching this glob or /regex/ (repeatable)")
+	fs.BoolVar(&o.noNormalize, "no-normalize", o.noNormalize, "crawl URLs exactly as linked instead of canonicalizing them first")
+	fs.Var(&o.stripParams, "strip-param", "query parameter to remove while normalizing, in addition to utm_* and other tracking parameters (repeatable, * wildcards allowed)")
+	fs.StringVar(&o.trailingSlash, "trailing-slash", o.trailingSlash, "trailing slash policy when normalizing: keep, add or remove")
+	fs.BoolVar(&o.lowercasePaths, "lowercase-paths", o.lowercasePaths, "treat URL paths as case-insensitive when normalizing")
+	fs.BoolVar(&o.sitemap, "sitemap", o.sitemap, "also crawl every URL in the site's sitemaps, found through robots.txt and /sitemap.xml")
+	fs.Var(&o.sitemapURLs, "sitemap-url", "sitemap to read instead of discovering them; implies --sitemap (repeatable)")
+	fs.StringVar(&o.userAgent, "user-agent", o.userAgent, "user agent sent with every request and matched against robots.txt groups")
+	fs.BoolVar(&o.external, "check-external", o.external, "check links to URLs outside the scope once, without crawling them")
+	fs.IntVar(&o.redirectHops, "max-redirect-hops", o.redirectHops, "flag redirect chains longer than this many hops (0 to disable)")
+	fs.Float64Var(&o.rps, "rps", o.rps, "maximum requests per second to each host (0 for unlimited)")
+	fs.IntVar(&o.burst, "burst", o.burst, "number of requests to a host allowed back to back before --rps applies")
+	fs.IntVar(&o.retries, "retries", o.retries, "times to retry a URL that couldn't be fetched")
+	fs.DurationVar(&o.retryBackoff, "retry-backoff", o.retryBackoff, "wait before the first retry, growing by the same amount for each retry after it")
+	fs.BoolVar(&o.ignoreRobots, "ignore-robots", o.ignoreRobots, "ignore robots.txt, meta robots and X-Robots-Tag (for auditing your own sites)")
+	fs.StringVar(&o.resume, "resume", o.resume, "save crawl progress in this directory, continuing the crawl saved there if there is one")
+	fs.StringVar(&o.order, "order", o.order, "which queued URL to crawl next: bfs (in the order found), dfs (deepest branch first) or priority (fewest clicks from the start URL first)")
+	fs.IntVar(&o.frontierMemory, "frontier-memory", o.frontierMemory, "number of queued URLs to keep in memory before writing the rest to disk")
+	fs.Var(&o.priority, "priority", "strategies for --order priority, each breaking ties left by the ones before: boost, sitemap, pages (HTML before assets) and depth (shallowest first); implies --order priority (default boost,sitemap,pages,depth)")
+	fs.Var(&o.boosts, "boost", "crawl URLs matching a glob or /regex/ sooner, as pattern=weight; a negative weight crawls them later; implies --order priority (repeatable)")
+	fs.StringVar(&o.spillDir, "spill-dir", o.spillDir, "directory for queued URLs past --frontier-memory (default: the system temporary directory)")
+	fs.StringVar(&o.registry, "registry", o.registry, "how to remember visited URLs: memory, disk (an on-disk store, for very large crawls) or bloom (a fixed-size Bloom filter that may skip a few URLs)")
+	fs.StringVar(&o.registryPath, "registry-path", o.registryPath, "file for --registry disk, replaced at the start of each crawl (default: a temporary file)")
+	fs.IntVar(&o.bloomCapacity, "bloom-capacity", o.bloomCapacity, "number of URLs to size --registry bloom for")
+	fs.Float64Var(&o.bloomFPRate, "bloom-fp-rate", o.bloomFPRate, "share of new URLs --registry bloom may wrongly skip while under capacity")
+}
+
+// validate checks option values that flag parsing can't
+func (o crawlOptions) validate() error {
+	switch o.mode {
+	case modeStatic, modeHeadless:
+	default:
+		return fmt.Errorf("unknown mode %q (want static or headless)", o.mode)
+	}
+	switch o.scope {
+	case scopeHost, scopeDomain, scopePath:
+	default:
+		return fmt.Errorf("unknown scope %q (want host, domain or path)", o.scope)
+	}
+	switch o.registry {
+	case registryMemory, registryDisk, registryBloom:
+	default:
+		return fmt.Errorf("unknown registry %q (want memory, disk or bloom)", o.registry)
+	}
+	if o.bloomCapacity <= 0 {
+		return fmt.Errorf("--bloom-capacity must be positive")
+	}
+	if o.bloomFPRate <= 0 || o.bloomFPRate >= 1 {
+		return fmt.Errorf("--bloom-fp-rate must be between 0 and 1")
+	}
+	if _, err := crawler.NewPatternScope(o.include, o.exclude); err != nil {
+		return err
+	}
+	if _, err := o.newNormalizer(); err != nil {
+		return err
+	}
+	if _, err := o.newFrontier(nil); err != nil {
+		return err
+	}
+	if o.concurrency < 0 || o.maxDepth < 0 || o.maxPages < 0 || o.maxDuration < 0 || o.rps < 0 || o.burst < 0 || o.redirectHops < 0 || o.retries < 0 || o.retryBackoff < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	return nil
+}
+
+func (o crawlOptions) headless() bool {
+	return o.mode == modeHeadless
+}
+
+// workers returns the configured concurrency, or a default based on the CPU count
+func (o crawlOptions) workers() int {
+	concurrency := o.concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU() * 2
+		if concurrency > maxConcurrency {
+			concurrency = maxConcurrency
+		}
+		if o.headless() && concurrency > maxHeadlessConcurrency {
+			concurrency = maxHeadlessConcurrency
+		}
+	}
+	return concurrency
+}
+
+// newScope combines the scope flags into a single Scope for base
+func (o crawlOptions) newScope(base *url.URL) (crawler.Scope, error) {
+	var scope crawler.Scope
+	switch o.scope {
+	case scopeDomain:
+		scope = crawler.NewDomainScope(base)
+	case scopePath:
+		scope = crawler.NewPathScope(base)
+	default:
+		scope = crawler.NewHostScope(base)
+	}
+
+	if len(o.allowHosts) > 0 {
+		scope = crawler.AnyOf(scope, crawler.NewHostListScope(o.allowHosts...))
+	}
+	// The path prefix applies to allowed hosts too
+	if o.pathPrefix != "" {
+		scope = crawler.AllOf(scope, crawler.NewPrefixScope(o.pathPrefix))
+	}
+	if len(o.include) > 0 || len(o.exclude) > 0 {
+		patterns, err := crawler.NewPatternScope(o.include, o.exclude)
+		if err != nil {
+			return nil, err
+		}
+		scope = crawler.AllOf(scope, patterns)
+	}
+	return scope, nil
+}
+
+// newNormalizer returns the URL normalizer for these options, or nil if
+// normalization is disabled
+func (o crawlOptions) newNormalizer() (*crawler.Normalizer, error) {
+	if o.noNormalize {
+		return nil, nil
+	}
+	return crawler.NewNormalizer(crawler.NormalizeOptions{
+		StripParams:   append(append([]string{}, crawler.DefaultTrackingParams...), o.stripParams...),
+		TrailingSlash: crawler.TrailingSlash(o.trailingSlash),
+		LowercasePath: o.lowercasePaths,
+	})
+}
+
+// newSitemap returns the Sitemap to seed the crawl from, or nil if sitemaps
+// are disabled
+func (o crawlOptions) newSitemap() *crawler.Sitemap {
+	if !o.sitemap && len(o.sitemapURLs) == 0 {
+		return nil
+	}
+	return crawler.NewSitemap(o.userAgent, o.sitemapURLs...)
+}
+
+// openJournal opens the journal directory given by --resume, or returns nil
+// if there is none
+func (o crawlOptions) openJournal() (*crawler.Journal, error) {
+	if o.resume == "" {
+		return nil, nil
+	}
+	return crawler.OpenJournal(o.resume)
+}
+
+// journalStartURL returns the start URL of the crawl saved in the --resume
+// directory, or "" if there is none
+func (o crawlOptions) journalStartURL() string {
+	journal, err := o.openJournal()
+	if err != nil || journal == nil {
+		return ""
+	}
+	defer journal.Close()
+	return journal.StartURL()
+}
+
+// newFrontier returns the queue of URLs waiting to be crawled. sitemap may
+// be nil, in which case the sitemap strategy is skipped.
+func (o crawlOptions) newFrontier(sitemap *crawler.Sitemap) (*crawler.Frontier, error) {
+	order := crawler.FrontierOrder(o.order)
+	if len(o.priority) > 0 || len(o.boosts) > 0 {
+		order = crawler.OrderPriority
+	}
+	var prioritizers []crawler.Prioritizer
+	if order == crawler.OrderPriority {
+		strategies := []string(o.priority)
+		if len(strategies) == 0 {
+			strategies = []string{priorityBoost, prioritySitemap, priorityPages, priorityDepth}
+		}
+		for _, strategy := range strategies {
+			switch strategy {
+			case priorityBoost:
+				boosts, err := o.patternBoosts()
+				if err != nil {
+					return nil, err
+				}
+				prioritizers = append(prioritizers, boosts)
+			case prioritySitemap:
+				if sitemap != nil {
+					prioritizers = append(prioritizers, crawler.SitemapPriority(sitemap))
+				}
+			case priorityPages:
+				prioritizers = append(prioritizers, crawler.PagesFirst())
+			case priorityDepth:
+				prioritizers = append(prioritizers, crawler.ShallowestFirst())
+			default:
+				return nil, fmt.Errorf("unknown priority strategy %q (want boost, sitemap, pages or depth)", strategy)
+			}
+		}
+	}
+	return crawler.NewFrontier(crawler.FrontierOptions{
+		Order:        order,
+		MemoryLimit:  o.frontierMemory,
+		SpillDir:     o.spillDir,
+		Prioritizers: prioritizers,
+	})
+}
+
+// patternBoosts parses the --boost flags
+func (o crawlOptions) patternBoosts() (crawler.Prioritizer, error) {
+	var boosts []crawler.PatternBoost
+	for _, b := range o.boosts {
+		i := strings.LastIndex(b, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid boost %q (want pattern=weight)", b)
+		}
+		weight, err := strconv.ParseFloat(b[i+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boost %q: weight must be a number", b)
+		}
+		boosts = append(boosts, crawler.PatternBoost{Pattern: b[:i], Boost: weight})
+	}
+	return crawler.NewPatternBoosts(boosts...)
+}
+
+// newRegistry returns the Registry selected by --registry, along with a
+// function that releases it once the crawl is over
+func (o crawlOptions) newRegistry() (crawler.Registry, func(), error) {
+	switch o.registry {
+	case registryDisk:
+		path := o.registryPath
+		if path == "" {
+			f, err := os.CreateTemp("", "huntsman-registry-*.db")
+			if err != nil {
+				return nil, nil, err
+			}
+			f.Close()
+			path = f.Name()
+		}
+		// Each crawl starts with nothing visited
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+		registry, err := crawler.NewDiskRegistry(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return registry, func() {
+			registry.Close()
+			if o.registryPath == "" {
+				os.Remove(path)
+			}
+		}, nil
+	case registryBloom:
+		registry, err := crawler.NewBloomRegistry(o.bloomCapacity, o.bloomFPRate)
+		if err != nil {
+			return nil, nil, err
+		}
+		return registry, func() {}, nil
+	default:
+		return crawler.NewInMemoryRegistry(), func() {}, nil
+	}
+}
+
+// newLinkIndex returns the index of inbound links to build during the crawl.
+// It is nil with --registry disk or bloom, which are meant for crawls too
+// large to keep every link in memory; referrers then fall back to the page
+// each URL was found on.
+func (o crawlOptions) newLinkIndex() *crawler.LinkIndex {
+	if o.registry != registryMemory {
+		return nil
+	}
+	return crawler.NewLinkIndex()
+}
+
+// newCrawler builds a StandardCrawler for base using these options. The
+// returned limiter is nil unless a requests-per-second limit is set. sitemap
+// and journal may be nil.
+func (o crawlOptions) newCrawler(base *url.URL, registry crawler.Registry, frontier *crawler.Frontier, links *crawler.LinkIndex, sitemap *crawler.Sitemap, journal *crawler.Journal) (*crawler.StandardCrawler, *crawler.RateLimitedCollector, error) {
+	normalizer, err := o.newNormalizer()
+	if err != nil {
+		return nil, nil, err
+	}
+	if normalizer != nil {
+		// Scopes compare against normalized links, so derive them from the normalized start URL
+		if u, err := url.Parse(normalizer.Normalize(base.String())); err == nil {
+			base = u
+		}
+	}
+	scope, err := o.newScope(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	if journal != nil && journal.Resumed() && journal.StartURL() != base.String() {
+		return nil, nil, fmt.Errorf("%s holds a crawl of %s, not %s", o.resume, journal.StartURL(), base)
+	}
+
+	var collector crawler.Collector
+	if o.headless() {
+		collector = crawler.NewHeadlessCollector()
+	} else {
+		collector = crawler.NewStaticCollector()
+	}
+
+	var limiter *crawler.RateLimitedCollector
+	if o.rps > 0 {
+		limiter = crawler.NewRateLimitedCollector(collector, o.rps, o.burst)
+		collector = limiter
+	}
+	// Retries wrap the limiter so every attempt is paced
+	if o.retries > 0 {
+		collector = crawler.NewRetryCollector(collector, o.retries, o.retryBackoff)
+	}
+
+	crawlerOpts := []crawler.Option{
+		crawler.WithLinkIndex(links),
+		crawler.WithUserAgent(o.userAgent),
+		crawler.WithFrontier(frontier),
+		crawler.WithScope(scope),
+		crawler.WithMaxDepth(o.maxDepth),
+		crawler.WithMaxPages(o.maxPages),
+		crawler.WithMaxDuration(o.maxDuration),
+		crawler.WithMaxRedirects(o.redirectHops),
+	}
+	if normalizer != nil {
+		crawlerOpts = append(crawlerOpts, crawler.WithNormalizer(normalizer))
+	}
+	if sitemap != nil {
+		crawlerOpts = append(crawlerOpts, crawler.WithSitemap(sitemap))
+	}
+	if journal != nil {
+		crawlerOpts = append(crawlerOpts, crawler.WithJournal(journal))
+	}
+	if o.external {
+		var checker crawler.Collector = crawler.NewLinkChecker()
+		if o.rps > 0 {
+			checker = crawler.NewRateLimitedCollector(checker, o.rps, o.burst)
+		}
+		if o.retries > 0 {
+			checker = crawler.NewRetryCollector(checker, o.retries, o.retryBackoff)
+		}
+		crawlerOpts = append(crawlerOpts, crawler.WithExternalLinks(checker))
+	}
+	if !o.ignoreRobots {
+		crawlerOpts = append(crawlerOpts, crawler.WithRobots(crawler.NewRobots(o.userAgent)))
+	}
+	return crawler.NewStandardCrawler(collector, registry, o.workers(), crawlerOpts...), limiter, nil
+}
+
+// crawlContext returns the context to run a crawl in. In headless mode it
+// carries a persistent browser shared by all workers.
+func (o crawlOptions) crawlContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if !o.headless() {
+		return context.WithCancel(parent)
+	}
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.DisableGPU,
+		chromedp.NoSandbox,
+		chromedp.Headless,
+		chromedp.UserAgent(o.userAgent),
+	)
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(parent, opts...)
+	ctx, cancel := chromedp.NewContext(allocCtx)
+
+	return ctx, func() {
+		cancel()
+		cancelAlloc()
+	}
+}
+
+// normalizeStartURL adds a scheme to bare hostnames and parses the result
+func normalizeStartURL(rawUrl string) (*url.URL, error) {
+	lower := strings.ToLower(rawUrl)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		rawUrl = "https://" + rawUrl
+	}
+	return url.Parse(rawUrl)
+}
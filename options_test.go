@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jturmel/huntsman/crawler"
+)
+
+func TestCrawlOptions_NewScope(t *testing.T) {
+	o := crawlOptions{scope: scopeHost, pathPrefix: "/docs", allowHosts: stringList{"cdn.example.com"}}
+	base, _ := url.Parse("https://example.com/")
+	scope, err := o.newScope(base)
+	if err != nil {
+		t.Fatalf("newScope failed: %v", err)
+	}
+
+	tests := map[string]bool{
+		"https://example.com/docs/a":     true,
+		"https://cdn.example.com/docs/a": true,
+		"https://example.com/blog":       false,
+		"https://cdn.example.com/lib.js": false,
+		"https://other.com/docs/a":       false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := scope.InScope(u); got != want {
+			t.Errorf("InScope(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestCrawlOptions_Retries(t *testing.T) {
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			// Drop the connection so the first fetch fails
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	o := defaultCrawlOptions()
+	o.mode = modeStatic
+	o.ignoreRobots = true
+	o.retries = 1
+	o.retryBackoff = time.Millisecond
+	base, _ := url.Parse(ts.URL + "/")
+	c, _, err := o.newCrawler(base, crawler.NewInMemoryRegistry(), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("newCrawler failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go c.Start(ctx, base.String())
+	retried, completed := 0, 0
+	for e := range c.Events() {
+		switch e.Kind {
+		case crawler.EventRetried:
+			retried++
+		case crawler.EventCompleted:
+			completed++
+		}
+	}
+	if retried != 1 || completed != 1 {
+		t.Errorf("Expected 1 retry and 1 completed page, got %d and %d", retried, completed)
+	}
+}
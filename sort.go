@@ -0,0 +1,168 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/jturmel/huntsman/crawler"
+)
+
+// Result table columns. Sort keys 1-5 pick them by position; 0 restores
+// arrival order.
+const (
+	colNone = iota
+	colURL
+	colStatus
+	colType
+	colSize
+	colFrom
+)
+
+var columnTitles = []string{"URL", "Status", "Type", "Size", "From Source"}
+
+// columnTitle returns the header for col, marked with the sort direction when
+// the table is sorted by it. Size is right aligned to match its cells.
+func (m model) columnTitle(col, width int) string {
+	title := columnTitles[col-1]
+	if m.sortColumn == col {
+		if m.sortDesc {
+			title += " ▼"
+		} else {
+			title += " ▲"
+		}
+	}
+	if col == colSize {
+		title = fmt.Sprintf("%*s", width, title)
+	}
+	return title
+}
+
+// updateColumnTitles refreshes the header after a sort change
+func (m *model) updateColumnTitles() {
+	columns := m.table.Columns()
+	for i := range columns {
+		columns[i].Title = m.columnTitle(i+1, columns[i].Width)
+	}
+	m.table.SetColumns(columns)
+}
+
+// sortBy sorts the table by col, flipping the direction when it is already
+// sorted by that column. The selected resource stays selected.
+func (m *model) sortBy(col int) {
+	if col == colNone {
+		m.sortColumn, m.sortDesc = colNone, false
+	} else if m.sortColumn == col {
+		m.sortDesc = !m.sortDesc
+	} else {
+		m.sortColumn, m.sortDesc = col, false
+	}
+	m.updateColumnTitles()
+
+	selected := -1
+	if cursor := m.table.Cursor(); cursor >= 0 && cursor < len(m.visible) {
+		selected = m.visible[cursor]
+	}
+	m.refreshRows()
+	if i := slices.Index(m.visible, selected); i >= 0 {
+		m.table.SetCursor(i)
+	}
+}
+
+// compareResources orders resources a and b by the sort column, falling back
+// to arrival order so that equal rows keep their place
+func (m model) compareResources(a, b int) int {
+	ra, rb := m.resources[a], m.resources[b]
+	var c int
+	switch m.sortColumn {
+	case colURL:
+		c = strings.Compare(ra.URL, rb.URL)
+	case colStatus:
+		c = compareStatus(ra.Status, rb.Status)
+	case colType:
+		c = strings.Compare(ra.Kind, rb.Kind)
+	case colSize:
+		c = cmp.Compare(ra.Size, rb.Size)
+	case colFrom:
+		c = strings.Compare(ra.FromSource, rb.FromSource)
+	}
+	if m.sortDesc {
+		c = -c
+	}
+	if c == 0 {
+		c = cmp.Compare(a, b)
+	}
+	return c
+}
+
+// compareStatus orders HTTP status codes numerically, ahead of labels such
+// as "Error" or "Blocked"
+func compareStatus(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// refreshRows rebuilds the visible rows from every result, applying the
+// filter and sort order
+func (m *model) refreshRows() {
+	m.visible = m.visible[:0]
+	for i, res := range m.resources {
+		if m.matchesFilter(res) {
+			m.visible = append(m.visible, i)
+		}
+	}
+	if m.sortColumn != colNone {
+		slices.SortFunc(m.visible, m.compareResources)
+	}
+	m.table.SetRows(m.visibleRows())
+}
+
+// addRow shows result i in its sorted position if it passes the filter
+func (m *model) addRow(i int) {
+	if !m.matchesFilter(m.resources[i]) {
+		return
+	}
+	pos := len(m.visible)
+	if m.sortColumn != colNone {
+		pos = sort.Search(len(m.visible), func(k int) bool {
+			return m.compareResources(i, m.visible[k]) < 0
+		})
+	}
+	m.visible = slices.Insert(m.visible, pos, i)
+	m.table.SetRows(m.visibleRows())
+
+	// Keep the selection on the same resource as rows arrive above it
+	if cursor := m.table.Cursor(); len(m.visible) > 1 && pos <= cursor {
+		m.table.SetCursor(cursor + 1)
+	}
+}
+
+func (m model) visibleRows() []table.Row {
+	rows := make([]table.Row, len(m.visible))
+	for k, i := range m.visible {
+		rows[k] = m.allRows[i]
+	}
+	return rows
+}
+
+// visibleResources returns the filtered results in display order
+func (m model) visibleResources() []crawler.Resource {
+	out := make([]crawler.Resource, len(m.visible))
+	for k, i := range m.visible {
+		out[k] = m.resources[i]
+	}
+	return out
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/jturmel/huntsman/crawler"
+)
+
+// newTestModel returns a model with the given results already received, in
+// order
+func newTestModel(resources ...crawler.Resource) model {
+	m := initialModel(defaultCrawlOptions(), exportOptions{})
+	for _, res := range resources {
+		receive(&m, res)
+	}
+	return m
+}
+
+// receive adds res to m as a crawl result arriving
+func receive(m *model, res crawler.Resource) {
+	m.resources = append(m.resources, res)
+	m.allRows = append(m.allRows, table.Row{res.URL, res.Status, res.Kind, formatSize(res.Size)})
+	m.addRow(len(m.resources) - 1)
+}
+
+// visibleURLs returns the URLs of the rows shown, in order
+func visibleURLs(m model) []string {
+	var urls []string
+	for _, res := range m.visibleResources() {
+		urls = append(urls, res.URL)
+	}
+	return urls
+}
+
+func TestCompareStatus(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"200", "200", 0},
+		{"200", "404", -1},
+		{"404", "200", 1},
+		{"99", "100", -1}, // Numeric, not lexical
+		{"500", "Error", -1},
+		{"Error", "500", 1},
+		{"Blocked by robots", "Error", -1},
+		{"Error", "Error", 0},
+	}
+	for _, tt := range tests {
+		if got := compareStatus(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareStatus(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestModel_SortBy(t *testing.T) {
+	m := newTestModel(
+		crawler.Resource{URL: "/b", Status: "404", Size: 30},
+		crawler.Resource{URL: "/c", Status: "Error", Size: 10},
+		crawler.Resource{URL: "/a", Status: "200", Size: 20},
+	)
+
+	steps := []struct {
+		col      int
+		want     []string
+		wantDesc bool
+	}{
+		{colSize, []string{"/c", "/a", "/b"}, false},
+		{colSize, []string{"/b", "/a", "/c"}, true},
+		{colStatus, []string{"/a", "/b", "/c"}, false},
+		{colURL, []string{"/a", "/b", "/c"}, false},
+		{colURL, []string{"/c", "/b", "/a"}, true},
+		{colNone, []string{"/b", "/c", "/a"}, false},
+	}
+	for _, step := range steps {
+		m.sortBy(step.col)
+		if got := visibleURLs(m); !slices.Equal(got, step.want) {
+			t.Errorf("sortBy(%d): expected %v, got %v", step.col, step.want, got)
+		}
+		if m.sortColumn != step.col || m.sortDesc != step.wantDesc {
+			t.Errorf("sortBy(%d): expected column %d desc %v, got %d desc %v",
+				step.col, step.col, step.wantDesc, m.sortColumn, m.sortDesc)
+		}
+	}
+}
+
+func TestModel_SortByKeepsSelection(t *testing.T) {
+	m := newTestModel(
+		crawler.Resource{URL: "/b", Size: 30},
+		crawler.Resource{URL: "/c", Size: 10},
+		crawler.Resource{URL: "/a", Size: 20},
+	)
+	m.table.SetCursor(1) // /c
+
+	for _, col := range []int{colSize, colSize, colURL, colNone} {
+		m.sortBy(col)
+		if res, ok := m.selectedResource(); !ok || res.URL != "/c" {
+			t.Errorf("sortBy(%d): expected /c to stay selected, got %q", col, res.URL)
+		}
+	}
+}
+
+func TestModel_AddRow(t *testing.T) {
+	tests := []struct {
+		name       string
+		sortColumn int
+		sortDesc   bool
+		arrive     []crawler.Resource
+		want       []string
+	}{
+		{
+			name:   "arrival order",
+			arrive: []crawler.Resource{{URL: "/b"}, {URL: "/a"}, {URL: "/c"}},
+			want:   []string{"/b", "/a", "/c"},
+		},
+		{
+			name:       "sorted insert",
+			sortColumn: colURL,
+			arrive:     []crawler.Resource{{URL: "/b"}, {URL: "/a"}, {URL: "/c"}},
+			want:       []string{"/a", "/b", "/c"},
+		},
+		{
+			name:       "descending, ties in arrival order",
+			sortColumn: colSize,
+			sortDesc:   true,
+			arrive:     []crawler.Resource{{URL: "/small", Size: 1}, {URL: "/big", Size: 9}, {URL: "/small2", Size: 1}},
+			want:       []string{"/big", "/small", "/small2"},
+		},
+	}
+	for _, tt := range tests {
+		m := newTestModel()
+		m.sortColumn, m.sortDesc = tt.sortColumn, tt.sortDesc
+		for _, res := range tt.arrive {
+			receive(&m, res)
+		}
+		if got := visibleURLs(m); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestModel_AddRowKeepsSelection(t *testing.T) {
+	m := newTestModel(crawler.Resource{URL: "/b"}, crawler.Resource{URL: "/d"})
+	m.sortBy(colURL)
+	m.table.SetCursor(1) // /d
+
+	// Rows arriving above the selection push the cursor down with it; rows
+	// below leave it alone
+	for _, u := range []string{"/a", "/e", "/c"} {
+		receive(&m, crawler.Resource{URL: u})
+		if res, ok := m.selectedResource(); !ok || res.URL != "/d" {
+			t.Errorf("After %s arrived: expected /d to stay selected, got %q", u, res.URL)
+		}
+	}
+
+	// Filtered-out rows don't move it either
+	m.filterInput.SetValue("-url:/f")
+	m.setFilter()
+	receive(&m, crawler.Resource{URL: "/f"})
+	if res, ok := m.selectedResource(); !ok || res.URL != "/d" {
+		t.Errorf("After a filtered row arrived: expected /d to stay selected, got %q", res.URL)
+	}
+}
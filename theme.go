@@ -14,6 +14,7 @@ type Theme struct {
 	CheckMarkColor  string `json:"check_mark_color"`
 	TableSelectedFG string `json:"table_selected_fg"`
 	TableSelectedBG string `json:"table_selected_bg"`
+	ErrorColor      string `json:"error_color"`
 }
 
 func DefaultTheme() Theme {
@@ -24,6 +25,7 @@ func DefaultTheme() Theme {
 		CheckMarkColor:  "#bd93f9",
 		TableSelectedFG: "229",
 		TableSelectedBG: "#bd93f9",
+		ErrorColor:      "#ff5555",
 	}
 }
 
@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/url"
-	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -13,7 +13,6 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/chromedp/chromedp"
 	"github.com/jturmel/huntsman/crawler"
 )
 
@@ -25,31 +24,66 @@ type model struct {
 	spinner     spinner.Model
 	table       table.Model
 	allRows     []table.Row
+	resources   []crawler.Resource
+	visible     []int // Indices into resources of the rows shown, in order
+	sortColumn  int
+	sortDesc    bool
 	visited     map[string]bool
 	baseUrl     *url.URL
 	width       int
 	height      int
 	crawler     crawler.Crawler
-	results     chan crawler.Resource
+	stopCrawl   func() // Stops the crawl and waits for it to end; safe to call again
+	links       *crawler.LinkIndex
+	sitemap     *crawler.Sitemap
+	journal     *crawler.Journal
+	limiter     *crawler.RateLimitedCollector
+	detail      *detailView
+	events      chan crawlEventMsg
+	stats       crawler.Stats
 	message     string
 	msgTimer    *time.Timer
+	startedAt   time.Time
+	finishedAt  time.Time
 	crawling    bool
+	paused      bool
 	finished    bool
 	filtering   bool
-	spaMode     bool
+	filter      *crawler.Filter
+	filterErr   error
+	opts        crawlOptions
+	exportOpts  exportOptions
 	theme       Theme
 }
 
 type clearMsg struct{}
 type crawlFinishedMsg struct{}
 
+// shutdown stops the crawl, if any, and closes its journal before quitting
+func (m *model) shutdown() {
+	if m.stopCrawl != nil {
+		m.stopCrawl()
+	}
+	if m.journal != nil {
+		m.journal.Close()
+		m.journal = nil
+	}
+}
+
+// crawlEventMsg is an event from a crawl. Events from a crawl that has been
+// replaced by a newer one are ignored.
+type crawlEventMsg struct {
+	crawler crawler.Crawler
+	event   crawler.Event
+}
+
 func (m model) Init() tea.Cmd {
-	return tea.Batch(textinput.Blink, m.waitForResults())
+	return tea.Batch(textinput.Blink, m.waitForEvents())
 }
 
-func (m model) waitForResults() tea.Cmd {
+func (m model) waitForEvents() tea.Cmd {
 	return func() tea.Msg {
-		return <-m.results
+		return <-m.events
 	}
 }
 
@@ -76,13 +110,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		columns := []table.Column{
-			{Title: "URL", Width: urlWidth},
-			{Title: "Status", Width: statusWidth},
-			{Title: "Type", Width: typeWidth},
-			{Title: "      Size", Width: sizeWidth},
-			{Title: "From Source", Width: fromWidth},
+			{Width: urlWidth},
+			{Width: statusWidth},
+			{Width: typeWidth},
+			{Width: sizeWidth},
+			{Width: fromWidth},
 		}
 		m.table.SetColumns(columns)
+		m.updateColumnTitles()
 
 		actualTableWidth := urlWidth + statusWidth + typeWidth + sizeWidth + fromWidth + 4 + 8 + 2
 		leftInputWidth := actualTableWidth / 2
@@ -100,28 +135,35 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.message = ""
 		return m, nil
 
-	case crawler.Resource:
-		if msg.URL == "__FINISHED__" {
+	case crawlEventMsg:
+		if msg.crawler != m.crawler {
+			return m, m.waitForEvents()
+		}
+		m.stats = msg.event.Stats
+		if msg.event.Kind == crawler.EventFinished {
 			m.crawling = false
+			m.paused = false
 			m.finished = true
-			return m, nil
+			m.finishedAt = time.Now()
+			if msg.event.Err != nil {
+				m.message = "Error: " + msg.event.Err.Error()
+			}
+			return m, m.waitForEvents()
+		}
+		if msg.event.Resource == nil {
+			return m, m.waitForEvents()
 		}
-		m.visited[msg.URL] = true
+		res := *msg.event.Resource
+		m.visited[res.URL] = true
+		m.resources = append(m.resources, res)
 
-		sizeKB := float64(msg.Size) / 1024.0
-		sizeStr := fmt.Sprintf("%.1f kB", sizeKB)
-		formattedSize := fmt.Sprintf("%10s", sizeStr)
+		formattedSize := fmt.Sprintf("%10s", formatSize(res.Size))
 
-		row := table.Row{msg.URL, msg.Status, msg.Kind, formattedSize, msg.FromSource}
+		row := table.Row{res.URL, res.Status, res.Kind, formattedSize, res.FromSource}
 		m.allRows = append(m.allRows, row)
+		m.addRow(len(m.resources) - 1)
 
-		if m.matchesFilter(msg.URL, msg.Kind, msg.Status, msg.FromSource) {
-			rows := m.table.Rows()
-			rows = append(rows, row)
-			m.table.SetRows(rows)
-		}
-
-		return m, m.waitForResults()
+		return m, m.waitForEvents()
 
 	case spinner.TickMsg:
 		var cmd tea.Cmd
@@ -129,29 +171,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case tea.KeyMsg:
+		if m.detail != nil {
+			return m.updateDetail(msg)
+		}
 		switch msg.String() {
 		case "ctrl+c":
-			if m.crawler != nil {
-				m.crawler.Stop()
-			}
+			m.shutdown()
 			return m, tea.Quit
 		case "q":
 			if !m.filtering && !m.textInput.Focused() {
-				if m.crawler != nil {
-					m.crawler.Stop()
-				}
+				m.shutdown()
 				return m, tea.Quit
 			}
 		case "esc":
 			if m.filtering {
 				m.filtering = false
 				m.filterInput.Blur()
-				m.table.SetRows(m.allRows)
+				m.filterInput.SetValue("")
+				m.setFilter()
 				return m, nil
 			}
-			if m.crawler != nil {
-				m.crawler.Stop()
-			}
+			m.shutdown()
 			return m, tea.Quit
 		case "tab":
 			if m.textInput.Focused() {
@@ -186,12 +226,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.filtering = true
 				m.filterInput.Focus()
 				m.filterInput.SetValue("")
+				m.setFilter()
 				return m, nil
 			}
 		case "s":
 			if !m.textInput.Focused() && !m.filtering {
-				m.spaMode = !m.spaMode
-				if m.spaMode {
+				if m.opts.headless() {
+					m.opts.mode = modeStatic
+				} else {
+					m.opts.mode = modeHeadless
+				}
+				if m.opts.headless() {
 					m.message = "SPA Mode Enabled (Headless)"
 				} else {
 					m.message = "Static Mode Enabled"
@@ -208,111 +253,163 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				rawUrl := m.textInput.Value()
 				if rawUrl != "" {
-					if !strings.HasPrefix(rawUrl, "http://") && !strings.HasPrefix(rawUrl, "https://") {
-						rawUrl = "https://" + rawUrl
+					parsedUrl, err := normalizeStartURL(rawUrl)
+					if err != nil {
+						return m, nil
 					}
 
-					parsedUrl, err := url.Parse(rawUrl)
+					// Each crawl reopens the journal so it picks up where the
+					// last one saved there stopped. The last crawl must have
+					// ended first, or it could still be writing to it.
+					if m.stopCrawl != nil {
+						m.stopCrawl()
+						m.crawling = false
+						m.paused = false
+					}
+					if m.journal != nil {
+						m.journal.Close()
+						m.journal = nil
+					}
+					journal, err := m.opts.openJournal()
 					if err != nil {
+						m.message = "Error: " + err.Error()
 						return m, nil
 					}
 
-					if m.crawler != nil {
-						m.crawler.Stop()
-						select {
-						case <-m.results:
-						default:
+					registry, closeRegistry, err := m.opts.newRegistry()
+					if err != nil {
+						if journal != nil {
+							journal.Close()
 						}
+						m.message = "Error: " + err.Error()
+						return m, nil
+					}
+
+					links := m.opts.newLinkIndex()
+					sitemap := m.opts.newSitemap()
+					// validate has already checked the frontier options
+					frontier, _ := m.opts.newFrontier(sitemap)
+					newCrawler, limiter, err := m.opts.newCrawler(parsedUrl, registry, frontier, links, sitemap, journal)
+					if err != nil {
+						closeRegistry()
+						if journal != nil {
+							journal.Close()
+						}
+						m.message = "Error: " + err.Error()
+						return m, nil
 					}
 
 					m.baseUrl = parsedUrl
 					m.visited = make(map[string]bool)
 					m.allRows = []table.Row{}
+					m.resources = nil
+					m.visible = nil
 					m.table.SetRows([]table.Row{})
 					m.textInput.Blur()
 					m.table.Focus()
 
-					concurrency := runtime.NumCPU() * 2
-					if concurrency > maxConcurrency {
-						concurrency = maxConcurrency
-					}
+					m.links = links
+					m.sitemap = sitemap
+					m.journal = journal
+					m.crawler = newCrawler
+					m.limiter = limiter
 
-					var collector crawler.Collector
-					if m.spaMode {
-						collector = crawler.NewHeadlessCollector()
-						if concurrency > 8 {
-							concurrency = 8
-						}
-					} else {
-						collector = crawler.NewStaticCollector()
-					}
-					
-					registry := crawler.NewInMemoryRegistry()
-					m.crawler = crawler.NewStandardCrawler(collector, registry, concurrency)
+					// Ask for events before starting, so a resumed crawl's
+					// replayed resources aren't dropped
+					events := m.crawler.Events()
 
 					// Start crawling in a goroutine
+					done := make(chan struct{})
 					go func() {
-						var ctx context.Context = context.Background()
-						var cancel context.CancelFunc = func() {}
-
-						if m.spaMode {
-							// Create persistent browser context
-							opts := append(chromedp.DefaultExecAllocatorOptions[:],
-								chromedp.DisableGPU,
-								chromedp.NoSandbox,
-								chromedp.Headless,
-							)
-							allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
-							ctx, cancel = chromedp.NewContext(allocCtx)
-							
-							// Chain cleanup
-							originalCancel := cancel
-							cancel = func() {
-								originalCancel()
-								cancelAlloc()
-							}
-						}
+						defer close(done)
+						ctx, cancel := m.opts.crawlContext(context.Background())
 						defer cancel()
 
 						_ = m.crawler.Start(ctx, m.baseUrl.String())
+						closeRegistry()
 					}()
 
-					// Forward results to m.results
-					go func() {
-						for res := range m.crawler.Results() {
-							m.results <- res
+					// Forward events to m.events. Once the crawl is stopped
+					// they are drained without forwarding, so stopCrawl can't
+					// wait on a full m.events.
+					stopped := make(chan struct{})
+					go func(c crawler.Crawler) {
+						for e := range events {
+							select {
+							case m.events <- crawlEventMsg{crawler: c, event: e}:
+							case <-stopped:
+							}
 						}
-						// Signal completion
-						m.results <- crawler.Resource{URL: "__FINISHED__"}
-					}()
+					}(m.crawler)
+
+					c := m.crawler
+					m.stopCrawl = sync.OnceFunc(func() {
+						close(stopped)
+						c.Stop()
+						<-done
+					})
 
 					m.crawling = true
+					m.paused = false
 					m.finished = false
+					m.stats = crawler.Stats{}
+					m.startedAt = time.Now()
+					m.finishedAt = time.Time{}
 
-					return m, tea.Batch(
-						m.waitForResults(),
-						m.spinner.Tick,
-					)
+					return m, m.spinner.Tick
 				}
 				return m, nil
 			} else if m.table.Focused() {
+				if res, ok := m.selectedResource(); ok {
+					m.detail = m.newDetailView(res)
+				}
+				return m, nil
+			}
+		case "o":
+			if m.table.Focused() && !m.filtering {
 				selectedRow := m.table.SelectedRow()
 				if len(selectedRow) > 0 {
-					url := selectedRow[0]
-					openURL(url)
+					openURL(selectedRow[0])
 				}
 			}
-		case "w":
-			if m.table.Focused() {
-				filename, err := m.exportToCSV()
-				if err != nil {
-					m.message = "Error exporting: " + err.Error()
+		case "w", "W":
+			if m.table.Focused() && !m.filtering {
+				return m.export(m.exportToCSV, msg.String() == "W")
+			}
+		case "e", "E":
+			if m.table.Focused() && !m.filtering {
+				return m.export(m.exportToJSON, msg.String() == "E")
+			}
+		case "c":
+			if m.table.Focused() && !m.filtering {
+				return m.showRedirects()
+			}
+		case "m":
+			if m.table.Focused() && !m.filtering {
+				return m.export(m.exportReport("md"), true)
+			}
+		case "h":
+			if m.table.Focused() && !m.filtering {
+				return m.export(m.exportReport("html"), true)
+			}
+		case "x":
+			if m.table.Focused() && !m.filtering {
+				return m.export(m.exportSitemap, true)
+			}
+		case "p":
+			if !m.textInput.Focused() && !m.filtering && m.crawling {
+				if m.paused {
+					m.crawler.Resume()
 				} else {
-					m.message = "Exported: " + filename
+					m.crawler.Pause()
 				}
-				return m, tea.Tick(time.Second*3, func(t time.Time) tea.Msg {
-					return clearMsg{}
-				})
+				m.paused = !m.paused
+				return m, nil
+			}
+		case "0", "1", "2", "3", "4", "5":
+			if m.table.Focused() && !m.filtering {
+				m.sortBy(int(msg.String()[0] - '0'))
+				return m, nil
 			}
 		}
 	}
@@ -325,13 +422,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		oldFilter := m.filterInput.Value()
 		m.filterInput, fiCmd = m.filterInput.Update(msg)
 		if m.filterInput.Value() != oldFilter {
-			var filteredRows []table.Row
-			for _, row := range m.allRows {
-				if m.matchesFilter(row[0], row[2], row[1], row[4]) {
-					filteredRows = append(filteredRows, row)
-				}
-			}
-			m.table.SetRows(filteredRows)
+			m.setFilter()
 		}
 	}
 	if m.table.Focused() {
@@ -341,40 +432,64 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(tiCmd, fiCmd, tCmd)
 }
 
-func (m model) matchesFilter(url, kind, status, from string) bool {
-	filter := strings.ToLower(m.filterInput.Value())
-	if filter == "" {
-		return true
+// export runs an exporter over the filtered view, or every result when all
+// is set, and reports where the file was written in the status line
+func (m model) export(exporter func(all bool) (string, int, error), all bool) (tea.Model, tea.Cmd) {
+	path, count, err := exporter(all)
+	if err != nil {
+		m.message = "Error exporting: " + err.Error()
+	} else {
+		scope := "filtered"
+		if all {
+			scope = "all"
+		}
+		m.message = fmt.Sprintf("Exported %d %s rows to %s", count, scope, path)
 	}
+	return m, tea.Tick(time.Second*5, func(t time.Time) tea.Msg {
+		return clearMsg{}
+	})
+}
 
-	typeFilter := ""
-	statusFilter := ""
-	fromFilter := ""
-	contentFilter := ""
-
-	// Parse advanced filters
-	parts := strings.Fields(filter)
-	var remainingParts []string
+// showRedirects puts the redirect chain of the selected row in the status line
+func (m model) showRedirects() (tea.Model, tea.Cmd) {
+	res, ok := m.selectedResource()
+	if !ok {
+		return m, nil
+	}
+	if chain := formatRedirects(res); chain != "" {
+		m.message = "Redirects: " + chain
+	} else {
+		m.message = "No redirects for " + res.URL
+	}
+	return m, tea.Tick(time.Second*10, func(t time.Time) tea.Msg {
+		return clearMsg{}
+	})
+}
 
-	for _, part := range parts {
-		if strings.HasPrefix(part, "type:") {
-			typeFilter = strings.TrimPrefix(part, "type:")
-		} else if strings.HasPrefix(part, "status:") {
-			statusFilter = strings.TrimPrefix(part, "status:")
-		} else if strings.HasPrefix(part, "from:") {
-			fromFilter = strings.TrimPrefix(part, "from:")
-		} else {
-			remainingParts = append(remainingParts, part)
-		}
+// selectedResource returns the resource behind the selected table row
+func (m model) selectedResource() (crawler.Resource, bool) {
+	cursor := m.table.Cursor()
+	if cursor < 0 || cursor >= len(m.visible) {
+		return crawler.Resource{}, false
 	}
-	contentFilter = strings.Join(remainingParts, " ")
+	return m.resources[m.visible[cursor]], true
+}
 
-	matchContent := contentFilter == "" || strings.Contains(strings.ToLower(url), contentFilter)
-	matchType := typeFilter == "" || strings.Contains(strings.ToLower(kind), typeFilter)
-	matchStatus := statusFilter == "" || strings.Contains(strings.ToLower(status), statusFilter)
-	matchFrom := fromFilter == "" || strings.Contains(strings.ToLower(from), fromFilter)
+// matchesFilter reports whether res passes the current filter
+func (m model) matchesFilter(res crawler.Resource) bool {
+	return m.filter.Match(res, m.links)
+}
 
-	return matchContent && matchType && matchStatus && matchFrom
+// setFilter parses the filter input. An invalid query is reported in the
+// filter box and the last valid one stays in effect.
+func (m *model) setFilter() {
+	filter, err := crawler.ParseFilter(m.filterInput.Value())
+	m.filterErr = err
+	if err != nil {
+		return
+	}
+	m.filter = filter
+	m.refreshRows()
 }
 
 func (m model) View() string {
@@ -386,23 +501,43 @@ func (m model) View() string {
 	checkMarkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.CheckMarkColor))
 	checkMark := checkMarkStyle.Render("✔")
 
-	if m.crawling {
+	if m.paused {
+		headerText += "• Paused ⏸ "
+	} else if m.crawling {
 		headerText += fmt.Sprintf("• Crawling %s ", m.spinner.View())
+		headerText += fmt.Sprintf("• %d queued, %d in flight ", m.stats.Queued, m.stats.InFlight)
+		if m.stats.Retries > 0 {
+			headerText += fmt.Sprintf("• %d retried ", m.stats.Retries)
+		}
 	} else if m.finished {
 		headerText += fmt.Sprintf("• Complete %s ", checkMark)
 	}
 
-	if m.spaMode {
+	if m.opts.headless() {
 		headerText += "• SPA Mode "
 	} else {
 		headerText += "• Static Mode "
 	}
 
+	if m.limiter != nil {
+		if pacing, rate := m.limiter.Pacing(), m.limiter.Rate(); pacing < rate {
+			headerText += fmt.Sprintf("• %.1f/%.1f req/s (backing off) ", pacing, rate)
+		} else {
+			headerText += fmt.Sprintf("• %.1f req/s ", rate)
+		}
+	}
+
 	if m.message != "" {
 		headerText += fmt.Sprintf("• %s ", m.message)
 	}
 
 	tableViewContent := m.table.View()
+	if m.detail != nil {
+		headerText = " Details "
+		if m.message != "" {
+			headerText += fmt.Sprintf("• %s ", m.message)
+		}
+	}
 	contentWidth := lipgloss.Width(tableViewContent)
 	if contentWidth == 0 {
 		columns := m.table.Columns()
@@ -449,6 +584,10 @@ func (m model) View() string {
 
 	inputView = left + inputTitle + right + "\n" + inputView
 
+	if m.detail != nil {
+		tableViewContent = m.detail.render(contentWidth, lipgloss.Height(tableViewContent), m.theme)
+	}
+
 	// Filter Input
 	var filterView string
 	filterStyle := blurredStyle.Copy().Width(rightInputWidth - 2)
@@ -460,7 +599,9 @@ func (m model) View() string {
 
 	// Add intersecting title for Filter Input
 	filterTitle := " Filter "
-	if m.filtering {
+	if m.filterErr != nil {
+		filterTitle = lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.ErrorColor)).Render(truncate(" Filter • "+m.filterErr.Error()+" ", rightInputWidth-4))
+	} else if m.filtering {
 		filterTitle = lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.FocusedColor)).Render(filterTitle)
 	}
 
@@ -514,10 +655,12 @@ func (m model) View() string {
 	tableView = left + resultsTitle + right + "\n" + tableView
 
 	var helpView string
-	if m.textInput.Focused() || m.filterInput.Focused() {
+	if m.detail != nil {
+		helpView = "Arrows/j/k: select link • Enter: show link details • o: open in browser • Esc: back • q: quit"
+	} else if m.textInput.Focused() || m.filterInput.Focused() {
 		helpView = "Tab: focus results • Enter: start crawl • Esc: quit"
 	} else {
-		helpView = "Tab: focus input • /: filter • s: toggle SPA • Enter: open URL • w: export • Arrows/j/k: scroll • q: quit"
+		helpView = "Tab: focus input • /: filter • s: toggle SPA • p: pause/resume • Enter: details • o: open URL • w/W: export CSV (filtered/all) • e/E: export JSON (filtered/all) • c: redirect chain • m/h: Markdown/HTML report • x: sitemap.xml • 1-5/0: sort by column/arrival • Arrows/j/k: scroll • q: quit"
 	}
 
 	helpStyle := lipgloss.NewStyle().PaddingLeft(1)
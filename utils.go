@@ -2,53 +2,322 @@ package main
 
 import (
 	"encoding/csv"
+	"flag"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/jturmel/huntsman/crawler"
 )
 
-func (m model) exportToCSV() (string, error) {
-	if m.baseUrl == nil {
-		return "", nil
+// formatSize renders a byte count the way the results table displays it
+func formatSize(size int64) string {
+	sizeKB := float64(size) / 1024.0
+	return fmt.Sprintf("%.1f kB", sizeKB)
+}
+
+// formatRedirects renders a redirect chain as "URL status → URL status → final",
+// followed by any issues found in it
+func formatRedirects(res crawler.Resource) string {
+	if len(res.Redirects) == 0 {
+		return ""
 	}
+	var b strings.Builder
+	for _, hop := range res.Redirects {
+		fmt.Fprintf(&b, "%s %s → ", hop.URL, hop.Status)
+	}
+	b.WriteString(res.Redirects[len(res.Redirects)-1].Location)
+	if len(res.RedirectIssues) > 0 {
+		fmt.Fprintf(&b, " [%s]", strings.Join(res.RedirectIssues, ", "))
+	}
+	return b.String()
+}
+
+// exportOptions controls where exports are written and what they contain
+type exportOptions struct {
+	dir  string
+	name string
+}
+
+func defaultExportOptions() exportOptions {
+	return exportOptions{name: "{timestamp}_{domain}"}
+}
+
+// register binds the options to flags on fs
+func (o *exportOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.dir, "export-dir", o.dir, "directory for exports (default ~/Downloads, then the home directory, then the current directory)")
+	fs.StringVar(&o.name, "export-name", o.name, "export filename template; supports {timestamp}, {date}, {domain} and {mode}")
+}
 
-	home, err := os.UserHomeDir()
+// exportDir returns the configured export directory, creating it if needed.
+// Without one it falls back to ~/Downloads, the home directory and finally
+// the current directory, so exports still work on servers without a home.
+func (o exportOptions) exportDir() (string, error) {
+	if o.dir != "" {
+		dir := o.dir
+		if strings.HasPrefix(dir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, dir[2:])
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+		return dir, nil
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		downloadsDir := filepath.Join(home, "Downloads")
+		if info, err := os.Stat(downloadsDir); err == nil && info.IsDir() {
+			return downloadsDir, nil
+		}
+		return home, nil
+	}
+	return os.Getwd()
+}
+
+// exportPath expands the filename template and returns the full path for an
+// export with the given extension
+func (m model) exportPath(ext string) (string, error) {
+	dir, err := m.exportOpts.exportDir()
 	if err != nil {
 		return "", err
 	}
 
-	downloadsDir := filepath.Join(home, "Downloads")
-	if _, err := os.Stat(downloadsDir); os.IsNotExist(err) {
-		downloadsDir = home
+	now := time.Now()
+	name := strings.NewReplacer(
+		"{timestamp}", now.Format("20060102_150405"),
+		"{date}", now.Format("20060102"),
+		"{domain}", exportDomain(m.baseUrl),
+		"{mode}", m.opts.mode,
+	).Replace(m.exportOpts.name)
+	if name == "" {
+		return "", fmt.Errorf("empty export filename")
+	}
+
+	filePath := filepath.Join(dir, name+"."+ext)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// exportDomain renders u's host for use in a filename: dots become dashes
+// and a port is joined with an underscore, as colons aren't allowed on
+// every filesystem
+func exportDomain(u *url.URL) string {
+	domain := strings.NewReplacer(".", "-", ":", "-").Replace(u.Hostname())
+	if port := u.Port(); port != "" {
+		domain += "_" + port
 	}
+	return domain
+}
 
-	timestamp := time.Now().Format("20060102_150405")
-	domain := strings.ReplaceAll(m.baseUrl.Host, ".", "-")
-	filename := fmt.Sprintf("%s_%s.csv", timestamp, domain)
-	filePath := filepath.Join(downloadsDir, filename)
+// exportRows returns the rows to export: the filtered view, or every row when all is set
+func (m model) exportRows(all bool) []table.Row {
+	if all {
+		return m.allRows
+	}
+	return m.table.Rows()
+}
+
+// exportResources returns the resources to export, applying the current
+// filter unless all is set
+func (m model) exportResources(all bool) []crawler.Resource {
+	if all {
+		return m.resources
+	}
+	return m.visibleResources()
+}
+
+func (m model) exportToCSV(all bool) (string, int, error) {
+	if m.baseUrl == nil {
+		return "", 0, fmt.Errorf("nothing to export")
+	}
+
+	filePath, err := m.exportPath("csv")
+	if err != nil {
+		return "", 0, err
+	}
 
 	file, err := os.Create(filePath)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	defer file.Close()
 
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
 
 	_ = writer.Write([]string{"URL", "Status", "Type", "Size", "From Source"})
 
-	for _, row := range m.table.Rows() {
+	rows := m.exportRows(all)
+	for _, row := range rows {
 		if err := writer.Write(row); err != nil {
-			return "", err
+			return "", 0, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", 0, err
+	}
+	return filePath, len(rows), nil
+}
+
+// crawlInfo describes the current crawl for exports
+func (m model) crawlInfo() crawler.CrawlInfo {
+	return crawler.CrawlInfo{
+		StartURL:   m.baseUrl.String(),
+		Mode:       m.opts.mode,
+		StartedAt:  m.startedAt,
+		FinishedAt: m.finishedAt,
+	}
+}
+
+// exportToJSON writes collected resources, unformatted and including
+// outgoing links, along with crawl metadata
+func (m model) exportToJSON(all bool) (string, int, error) {
+	if m.baseUrl == nil {
+		return "", 0, fmt.Errorf("nothing to export")
+	}
+
+	filePath, err := m.exportPath("json")
+	if err != nil {
+		return "", 0, err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	resources := m.exportResources(all)
+	if err := crawler.WriteJSON(file, m.crawlInfo(), resources); err != nil {
+		return "", 0, err
+	}
+
+	return filePath, len(resources), nil
+}
+
+// exportReport writes a Markdown or HTML report over every resource. Reports
+// always cover the whole crawl, so the all flag is ignored.
+func (m model) exportReport(ext string) func(all bool) (string, int, error) {
+	return func(all bool) (string, int, error) {
+		if m.baseUrl == nil {
+			return "", 0, fmt.Errorf("nothing to export")
+		}
+
+		filePath, err := m.exportPath(ext)
+		if err != nil {
+			return "", 0, err
+		}
+		if err := writeReport(filePath, m.crawlInfo(), m.resources, m.links, m.sitemap); err != nil {
+			return "", 0, err
+		}
+		return filePath, len(m.resources), nil
+	}
+}
+
+// isReportPath reports whether path has an extension writeReport understands
+func isReportPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".html", ".htm":
+		return true
+	}
+	return false
+}
+
+// writeReport writes a crawl report to path, choosing Markdown or HTML by
+// extension. sitemap may be nil if the crawl wasn't seeded from one.
+func writeReport(path string, info crawler.CrawlInfo, resources []crawler.Resource, links *crawler.LinkIndex, sitemap *crawler.Sitemap) error {
+	report := crawler.NewReport(info, resources, links)
+	if sitemap != nil {
+		for _, u := range sitemap.URLs() {
+			report.Sitemap = append(report.Sitemap, u.Loc)
 		}
 	}
 
-	return filename, nil
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		write = report.WriteMarkdown
+	case ".html", ".htm":
+		write = report.WriteHTML
+	default:
+		return fmt.Errorf("unknown report format %q (want .md or .html)", filepath.Ext(path))
+	}
+	return writeFile(path, write)
+}
+
+// exportSitemap writes a sitemap.xml of every indexable page. Sitemaps
+// always cover the whole crawl, so the all flag is ignored.
+func (m model) exportSitemap(all bool) (string, int, error) {
+	if m.baseUrl == nil {
+		return "", 0, fmt.Errorf("nothing to export")
+	}
+
+	filePath, err := m.exportPath("xml")
+	if err != nil {
+		return "", 0, err
+	}
+	count, err := writeSitemap(filePath, m.baseUrl, m.resources)
+	if err != nil {
+		return "", 0, err
+	}
+	return filePath, count, nil
+}
+
+// writeSitemap writes a sitemap of the indexable pages in resources to path
+// and returns how many URLs it lists. Past crawler.MaxSitemapURLs the pages
+// are split into numbered files next to path, and path becomes a sitemap
+// index pointing at them under base's site root.
+func writeSitemap(path string, base *url.URL, resources []crawler.Resource) (int, error) {
+	entries := crawler.SitemapEntries(resources)
+	if len(entries) <= crawler.MaxSitemapURLs {
+		return len(entries), writeFile(path, func(w io.Writer) error {
+			return crawler.WriteSitemap(w, entries)
+		})
+	}
+
+	stem := strings.TrimSuffix(path, filepath.Ext(path))
+	var locs []string
+	for i := 0; i*crawler.MaxSitemapURLs < len(entries); i++ {
+		part := entries[i*crawler.MaxSitemapURLs : min((i+1)*crawler.MaxSitemapURLs, len(entries))]
+		partPath := fmt.Sprintf("%s-%d.xml", stem, i+1)
+		if err := writeFile(partPath, func(w io.Writer) error {
+			return crawler.WriteSitemap(w, part)
+		}); err != nil {
+			return 0, err
+		}
+		locs = append(locs, base.Scheme+"://"+base.Host+"/"+filepath.Base(partPath))
+	}
+	return len(entries), writeFile(path, func(w io.Writer) error {
+		return crawler.WriteSitemapIndex(w, locs)
+	})
+}
+
+// writeFile creates path and fills it with write
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return err
+	}
+	return file.Close()
 }
 
 func openURL(u string) {
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportDomain(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.example.com/", "www-example-com"},
+		{"http://localhost:8080/docs", "localhost_8080"},
+		{"http://127.0.0.1:3000", "127-0-0-1_3000"},
+		{"http://[::1]:8080/", "--1_8080"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := exportDomain(u); got != tt.want {
+			t.Errorf("exportDomain(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestModel_ExportPath(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestModel()
+	m.baseUrl, _ = url.Parse("http://localhost:8080/")
+	m.opts.mode = "static"
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"{domain}_{mode}", "localhost_8080_static.csv"},
+		{"{date}", time.Now().Format("20060102") + ".csv"},
+		{"reports/{domain}", filepath.Join("reports", "localhost_8080.csv")},
+	}
+	for _, tt := range tests {
+		m.exportOpts = exportOptions{dir: dir, name: tt.name}
+		got, err := m.exportPath("csv")
+		if err != nil {
+			t.Errorf("exportPath with %q failed: %v", tt.name, err)
+			continue
+		}
+		if want := filepath.Join(dir, tt.want); got != want {
+			t.Errorf("exportPath with %q = %q, want %q", tt.name, got, want)
+		}
+		if strings.Contains(filepath.Base(got), ":") {
+			t.Errorf("exportPath with %q put a colon in the filename: %q", tt.name, got)
+		}
+	}
+
+	m.exportOpts = exportOptions{dir: dir, name: "{timestamp}"}
+	got, err := m.exportPath("csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := time.Parse("20060102_150405", strings.TrimSuffix(filepath.Base(got), ".csv")); err != nil {
+		t.Errorf("Expected {timestamp} to be expanded, got %q", got)
+	}
+
+	m.exportOpts = exportOptions{dir: dir}
+	if _, err := m.exportPath("csv"); err == nil {
+		t.Error("Expected an empty filename to fail")
+	}
+}
+
+func TestExportOptions_ExportDir(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("the home directory isn't read from $HOME")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// The configured directory is created, with ~ expanded
+	got, err := exportOptions{dir: "~/reports"}.exportDir()
+	if want := filepath.Join(home, "reports"); err != nil || got != want {
+		t.Errorf("Expected %q, got %q (%v)", want, got, err)
+	}
+	if info, err := os.Stat(got); err != nil || !info.IsDir() {
+		t.Errorf("Expected %q to be created", got)
+	}
+
+	// Without one, exports go to the home directory...
+	if got, err := (exportOptions{}).exportDir(); err != nil || got != home {
+		t.Errorf("Expected the home directory %q, got %q (%v)", home, got, err)
+	}
+
+	// ...or ~/Downloads when it exists
+	downloads := filepath.Join(home, "Downloads")
+	if err := os.Mkdir(downloads, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := (exportOptions{}).exportDir(); err != nil || got != downloads {
+		t.Errorf("Expected %q, got %q (%v)", downloads, got, err)
+	}
+
+	// ...and the current directory without a home
+	t.Setenv("HOME", "")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := (exportOptions{}).exportDir(); err != nil || got != wd {
+		t.Errorf("Expected the current directory %q, got %q (%v)", wd, got, err)
+	}
+}